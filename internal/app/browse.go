@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/hn"
+	"github.com/toalaah/hn/pkg/storylist"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// browser shows a list of stories, opening a thread view for the selected story and returning to the list once the
+// thread view is quit.
+type browser struct {
+	list   *storylist.Model
+	thread *threadview.Model
+	source hn.Source
+	load   func(ctx context.Context) ([]*hn.Story, error)
+	opts   Options
+	size   tea.WindowSizeMsg
+}
+
+type storiesLoadedMsg struct {
+	stories []*hn.Story
+	err     error
+}
+
+type threadLoadedMsg struct {
+	thread *hn.Story
+	// The ID of the comment to select once the thread is opened, if any.
+	selected int
+	err      error
+}
+
+type closeThreadMsg struct{}
+
+// Browse runs a program listing the stories returned by load, fetching selected stories through src.
+func Browse(title string, src hn.Source, load func(ctx context.Context) ([]*hn.Story, error), opts Options) error {
+	b := &browser{
+		list:   storylist.New(title, nil, opts.List...),
+		source: src,
+		load:   load,
+		opts:   opts,
+	}
+	b.list.SetStatus("Loading stories...")
+	_, err := tea.NewProgram(b,
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	).Run()
+	return err
+}
+
+func (b *browser) Init() tea.Cmd {
+	return func() tea.Msg {
+		stories, err := b.load(context.Background())
+		return storiesLoadedMsg{stories, err}
+	}
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.size = msg
+		// The list is hidden while a thread is open, but must still be up to date once it is shown again.
+		if b.thread != nil {
+			b.list.Update(msg)
+		}
+	case storiesLoadedMsg:
+		if msg.err != nil {
+			b.list.SetStatus(fmt.Sprintf("Error fetching stories: %s", msg.err))
+			return b, nil
+		}
+		b.list.SetStatus("")
+		b.list.SetStories(msg.stories)
+		return b, nil
+	case storylist.OpenMsg:
+		b.list.SetStatus("Loading thread...")
+		// Comments are opened in the context of their story.
+		if t := msg.Story; t.StoryID != 0 && t.StoryID != t.Id {
+			return b, b.fetchThread(t.StoryID, t.Id)
+		}
+		return b, b.fetchThread(msg.Story.Id, 0)
+	case threadLoadedMsg:
+		if msg.err != nil {
+			b.list.SetStatus(fmt.Sprintf("Error fetching thread: %s", msg.err))
+			return b, nil
+		}
+		b.list.SetStatus("")
+		trackVisit(msg.thread)
+		msg.thread.SetRenderer(b.opts.Renderer)
+		opts := append(slices.Clone(b.opts.Thread), threadview.WithQuitCmd(func() tea.Msg { return closeThreadMsg{} }))
+		m, err := threadview.New(msg.thread, threadOptions(opts...)...)
+		if err != nil {
+			b.list.SetStatus(fmt.Sprintf("Error opening thread: %s", err))
+			return b, nil
+		}
+		selectComment(m, msg.thread, msg.selected)
+		b.thread = m
+		_, cmd := b.thread.Update(b.size)
+		return b, tea.Batch(m.Init(), cmd)
+	case closeThreadMsg:
+		b.thread = nil
+		return b, nil
+	}
+
+	if b.thread != nil {
+		_, cmd := b.thread.Update(msg)
+		return b, cmd
+	}
+	_, cmd := b.list.Update(msg)
+	return b, cmd
+}
+
+func (b *browser) View() string {
+	if b.thread != nil {
+		return b.thread.View()
+	}
+	return b.list.View()
+}
+
+func (b *browser) fetchThread(id, selected int) tea.Cmd {
+	return func() tea.Msg {
+		t, err := b.source.Thread(context.Background(), id)
+		return threadLoadedMsg{t, selected, err}
+	}
+}
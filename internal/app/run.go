@@ -2,20 +2,47 @@ package app
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/hn"
+	"github.com/toalaah/hn/pkg/storylist"
 	"github.com/toalaah/hn/pkg/threadview"
 )
 
-func Run(t threadview.Thread) error {
-	m, err := threadview.New(t,
-		threadview.WithHeadSelectable(false),
-		threadview.WithHideCollapsedChildren(true),
-	)
+// Options configures the views shown by the program.
+type Options struct {
+	Thread   []threadview.Option
+	List     []storylist.Option
+	Renderer *hn.Renderer
+}
+
+// Run runs a program showing the thread t, with the comment with the given ID selected if it is not 0.
+func Run(t *hn.Story, selected int, opts Options) error {
+	trackVisit(t)
+	t.SetRenderer(opts.Renderer)
+	m, err := threadview.New(t, threadOptions(opts.Thread...)...)
 	if err != nil {
 		return err
 	}
+	selectComment(m, t, selected)
 	_, err = tea.NewProgram(m,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	).Run()
 	return err
 }
+
+// selectComment selects the comment of t with the given ID, if any.
+func selectComment(m *threadview.Model, t *hn.Story, id int) {
+	if c, ok := t.Find(id); ok && id != 0 {
+		if i, ok := t.CommentIndex(c); ok {
+			m.SelectIndex(i)
+		}
+	}
+}
+
+// threadOptions returns the options shared by all thread views, followed by any extra options.
+func threadOptions(extra ...threadview.Option) []threadview.Option {
+	return append([]threadview.Option{
+		threadview.WithHeadSelectable(false),
+		threadview.WithHideCollapsedChildren(true),
+	}, extra...)
+}
@@ -0,0 +1,16 @@
+package app
+
+import (
+	"github.com/toalaah/hn/pkg/hn"
+)
+
+// trackVisit marks the comments of t added since its previous visit as unread and records the current visit. Read
+// state is best-effort, failing to load or persist it never prevents a thread from being shown.
+func trackVisit(t *hn.Story) {
+	dir, err := hn.DefaultStateDir()
+	if err != nil {
+		return
+	}
+	rs := &hn.ReadState{Dir: dir}
+	_ = rs.Track(t)
+}
@@ -0,0 +1,197 @@
+// Package config loads user preferences from a configuration file.
+//
+// The file uses TOML. Values are read as strings, integers, booleans or arrays of strings.
+//
+//	[keys]
+//	up = ["k", "up"]
+//	quit = "q"
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the parsed contents of a configuration file.
+type Config struct {
+	tables map[string]map[string]any
+	// Keys in the order in which they appear in each table.
+	order map[string][]string
+}
+
+// DefaultPath returns the location of the configuration file, usually `$XDG_CONFIG_HOME/hn/config`.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hn", "config"), nil
+}
+
+// Load parses the configuration file at path. A missing file yields an empty configuration.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Parse(strings.NewReader(""))
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Parse reads a configuration from r.
+func Parse(r io.Reader) (*Config, error) {
+	c := &Config{
+		tables: map[string]map[string]any{"": {}},
+		order:  map[string][]string{},
+	}
+	var raw map[string]any
+	md, err := toml.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+	// Tables are flattened, such that e.g `[themes.mine]` becomes the table "themes.mine".
+	for _, k := range md.Keys() {
+		switch md.Type(k...) {
+		case "Hash":
+			if _, ok := c.tables[k.String()]; !ok {
+				c.tables[k.String()] = map[string]any{}
+			}
+			continue
+		case "ArrayHash":
+			continue
+		}
+		v, ok := lookup(raw, k)
+		if !ok {
+			// Keys within arrays of tables, which are not supported.
+			continue
+		}
+		table, key := strings.Join(k[:len(k)-1], "."), k[len(k)-1]
+		if _, ok := c.tables[table]; !ok {
+			c.tables[table] = map[string]any{}
+		}
+		c.tables[table][key] = normalize(v)
+		c.order[table] = append(c.order[table], key)
+	}
+	return c, nil
+}
+
+// lookup returns the value at the given path within the decoded document.
+func lookup(m map[string]any, k toml.Key) (any, bool) {
+	for i, name := range k {
+		v, ok := m[name]
+		if !ok {
+			return nil, false
+		}
+		if i == len(k)-1 {
+			return v, true
+		}
+		if m, ok = v.(map[string]any); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// normalize converts decoded values to the types expected by the accessors.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int64:
+		return int(v)
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return v
+			}
+			strs = append(strs, s)
+		}
+		return strs
+	}
+	return v
+}
+
+// Keys returns the keys defined in the given table, in the order they appear in the file.
+func (c *Config) Keys(table string) []string {
+	return slices.Clone(c.order[table])
+}
+
+// Has reports whether the key is defined in the given table.
+func (c *Config) Has(table, key string) bool {
+	_, ok := c.tables[table][key]
+	return ok
+}
+
+// String returns the string value of the key, or def if it is undefined.
+func (c *Config) String(table, key, def string) (string, error) {
+	v, ok := c.tables[table][key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", typeError(table, key, "string")
+	}
+	return s, nil
+}
+
+// Strings returns the value of the key as a list. Single strings are treated as a list of one element.
+func (c *Config) Strings(table, key string, def []string) ([]string, error) {
+	v, ok := c.tables[table][key]
+	if !ok {
+		return def, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	}
+	return nil, typeError(table, key, "list of strings")
+}
+
+// Bool returns the boolean value of the key, or def if it is undefined.
+func (c *Config) Bool(table, key string, def bool) (bool, error) {
+	v, ok := c.tables[table][key]
+	if !ok {
+		return def, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, typeError(table, key, "boolean")
+	}
+	return b, nil
+}
+
+// Int returns the integer value of the key, or def if it is undefined.
+func (c *Config) Int(table, key string, def int) (int, error) {
+	v, ok := c.tables[table][key]
+	if !ok {
+		return def, nil
+	}
+	n, ok := v.(int)
+	if !ok {
+		return 0, typeError(table, key, "integer")
+	}
+	return n, nil
+}
+
+func typeError(table, key, want string) error {
+	if table != "" {
+		key = table + "." + key
+	}
+	return fmt.Errorf("%s: expected %s", key, want)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// KeyMap returns the default thread view key map, overridden by the bindings of the `[keys]` table. Each entry maps the
+// name of an action to the list of keys triggering it.
+func (c *Config) KeyMap() (threadview.KeyMap, error) {
+	km := threadview.DefaultKeyMap()
+	named := km.Named()
+	for _, name := range c.Keys("keys") {
+		b, ok := named[name]
+		if !ok {
+			return km, fmt.Errorf("keys.%s: unknown action", name)
+		}
+		keys, err := c.Strings("keys", name, nil)
+		if err != nil {
+			return km, err
+		}
+		*b = key.NewBinding(key.WithKeys(keys...))
+	}
+	if err := km.Validate(); err != nil {
+		return km, fmt.Errorf("invalid key bindings: %w", err)
+	}
+	return km, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/toalaah/hn/pkg/hn"
+)
+
+// Theme returns the theme named by the top-level `theme` key. Besides the built-in themes, custom themes may be defined
+// in `[themes.<name>]` tables mapping style names to styles. Custom themes inherit all styles they do not set from the
+// theme named by their `base` key, which defaults to the built-in theme of the same name, if any, or the default theme.
+// This allows adjusting a built-in theme by defining a table of the same name.
+func (c *Config) Theme() (hn.Theme, error) {
+	name, err := c.String("", "theme", hn.DefaultTheme)
+	if err != nil {
+		return hn.Theme{}, err
+	}
+	t, err := c.theme(name, map[string]bool{})
+	if err != nil {
+		return t, err
+	}
+	if err := t.Validate(); err != nil {
+		return t, fmt.Errorf("theme %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func (c *Config) theme(name string, seen map[string]bool) (hn.Theme, error) {
+	table := "themes." + name
+	if _, ok := c.tables[table]; !ok {
+		if t, ok := hn.Themes[name]; ok {
+			return t, nil
+		}
+		return hn.Theme{}, fmt.Errorf("unknown theme: %s", name)
+	}
+	if seen[name] {
+		return hn.Theme{}, fmt.Errorf("theme %s inherits from itself", name)
+	}
+	seen[name] = true
+
+	defaultBase := hn.DefaultTheme
+	if _, ok := hn.Themes[name]; ok {
+		defaultBase = name
+	}
+	base, err := c.String(table, "base", defaultBase)
+	if err != nil {
+		return hn.Theme{}, err
+	}
+	// The table hides the built-in theme of the same name, which is only reachable as its base.
+	t, ok := hn.Themes[base]
+	if base != name || !ok {
+		if t, err = c.theme(base, seen); err != nil {
+			return t, err
+		}
+	}
+	named := t.Named()
+	for _, k := range c.Keys(table) {
+		if k == "base" {
+			continue
+		}
+		style, ok := named[k]
+		if !ok {
+			return t, fmt.Errorf("%s.%s: unknown style", table, k)
+		}
+		if *style, err = c.String(table, k, ""); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
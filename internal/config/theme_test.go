@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toalaah/hn/pkg/hn"
+)
+
+func TestTheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		link   string
+		text   string
+		err    bool
+	}{
+		{
+			name:   "builtin",
+			config: `theme = "light"`,
+			link:   hn.Themes["light"].Link,
+			text:   hn.Themes["light"].Text,
+		},
+		{
+			name: "adjusted builtin",
+			config: `theme = "dark"
+[themes.dark]
+link = "blue"`,
+			link: "blue",
+			text: hn.Themes["dark"].Text,
+		},
+		{
+			name: "adjusted default",
+			config: `[themes.light]
+link = "blue"
+[themes.dark]
+base = "light"`,
+			link: "blue",
+			text: hn.Themes["light"].Text,
+		},
+		{
+			name: "custom",
+			config: `theme = "mine"
+[themes.mine]
+base = "light"
+link = "red"`,
+			link: "red",
+			text: hn.Themes["light"].Text,
+		},
+		{
+			name: "custom without base",
+			config: `theme = "mine"
+[themes.mine]
+link = "red"`,
+			link: "red",
+			text: hn.Themes[hn.DefaultTheme].Text,
+		},
+		{
+			name: "cycle",
+			config: `theme = "a"
+[themes.a]
+base = "b"
+[themes.b]
+base = "a"`,
+			err: true,
+		},
+		{
+			name:   "unknown theme",
+			config: `theme = "nope"`,
+			err:    true,
+		},
+		{
+			name: "unknown style",
+			config: `theme = "dark"
+[themes.dark]
+nope = "red"`,
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			theme, err := c.Theme()
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if theme.Link != tt.link || theme.Text != tt.text {
+				t.Errorf("got link %q and text %q, want %q and %q", theme.Link, theme.Text, tt.link, tt.text)
+			}
+		})
+	}
+	// Built-in themes are never changed by adjusting them.
+	if hn.Themes["dark"].Link == "blue" {
+		t.Error("built-in theme was modified")
+	}
+}
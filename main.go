@@ -1,16 +1,26 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	_ "embed"
 	"flag"
-	"github.com/fatih/color"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/toalaah/hn/internal/app"
+	"github.com/toalaah/hn/internal/config"
 	"github.com/toalaah/hn/pkg/hn"
+	"github.com/toalaah/hn/pkg/storylist"
+	"github.com/toalaah/hn/pkg/threadview"
 )
 
 var (
@@ -18,22 +28,50 @@ var (
 	license string
 	prog    = filepath.Base(os.Args[0])
 	version = "0.0.1"
+	lists   = map[string]hn.List{
+		"top":  hn.ListTop,
+		"new":  hn.ListNew,
+		"best": hn.ListBest,
+		"ask":  hn.ListAsk,
+		"show": hn.ListShow,
+		"jobs": hn.ListJobs,
+	}
 )
 
 func main() {
 	var (
 		id          int
+		selected    int
 		err         error
 		t           *hn.Story
 		showVersion *bool
 		// Certainly, there is a better way to do this. But it works...
 		showVersionShort *bool
+		source           *string
+		offline          *bool
+		maxAge           *time.Duration
+		format           *string
+		configPath       *string
+		ascii            *bool
+		width            *string
+		watch            *time.Duration
 	)
 
-	color.Unset()
 	flag.Usage = func() {
-		fmt.Printf("Usage: %s [-v][-h] id\n", prog)
+		fmt.Printf("Usage: %s [-v][-h][-source name][-offline][-max-age duration][-format f][-config path][-ascii][-width w][-watch d] id|url|-\n", prog)
+		fmt.Printf("       %s [flags] top|new|best|ask|show|jobs\n", prog)
+		fmt.Printf("       %s [flags] search [-tags story|comment][-author name][-since d][-min-points n][-by-date] query\n", prog)
+		fmt.Printf("\nThreads are given by ID, by a link to news.ycombinator.com or hn.algolia.com, or as - to read either\n")
+		fmt.Printf("from stdin. Links to comments open their story with the comment selected.\n")
 		fmt.Printf("\nFlags:\n")
+		fmt.Printf("  -source name  backend to fetch from, either 'algolia' (default), 'firebase' or 'firebase-lazy'\n")
+		fmt.Printf("  -offline      only read threads from the on-disk cache\n")
+		fmt.Printf("  -max-age d    serve cached threads younger than d without refetching (default 5m)\n")
+		fmt.Printf("  -format f     write the thread to stdout as text, json, markdown or html instead of opening it\n")
+		fmt.Printf("  -config path  read configuration from path (default $XDG_CONFIG_HOME/hn/config)\n")
+		fmt.Printf("  -ascii        render without colors, using textual cues instead (implied by NO_COLOR and TERM=dumb)\n")
+		fmt.Printf("  -width w      wrap text at a fixed width w or at the window edge (fill)\n")
+		fmt.Printf("  -watch d      refresh the thread every d, e.g 1m, to pick up new comments\n")
 		fmt.Printf("  -version, -v  print version and exit\n")
 		fmt.Printf("  -h            print this usage and exit\n")
 		fmt.Printf("\n%s", license)
@@ -41,16 +79,117 @@ func main() {
 
 	showVersion = flag.Bool("version", false, "Show version and exit")
 	showVersionShort = flag.Bool("v", false, "Show version and exit")
+	source = flag.String("source", "algolia", "Backend to fetch from")
+	offline = flag.Bool("offline", false, "Only read threads from the cache")
+	maxAge = flag.Duration("max-age", 5*time.Minute, "Maximum age of cached threads")
+	format = flag.String("format", "", "Output format")
+	configPath = flag.String("config", "", "Configuration file")
+	ascii = flag.Bool("ascii", false, "Render without colors")
+	width = flag.String("width", "", "Text width policy")
+	watch = flag.Duration("watch", 0, "Refresh interval")
 	flag.Parse()
 	if *showVersion || *showVersionShort {
 		fmt.Printf("%s\n", version)
 		os.Exit(0)
 	}
 
-	if arg := flag.Arg(0); arg == "" {
+	// The configuration only affects the interactive views, so it is loaded once they are started.
+	loadOptions := func() app.Options {
+		opts, err := options(*configPath, *ascii, *width, *watch)
+		if err != nil {
+			fmt.Printf("Error loading config: %s\n", err)
+			os.Exit(1)
+		}
+		return opts
+	}
+
+	// Fall back to plain text if the output is not a terminal, e.g when piping into another program.
+	if *format == "" && !isTerminal(os.Stdout) {
+		*format = string(hn.FormatText)
+	}
+
+	switch *source {
+	case "algolia":
+		hn.DefaultSource = hn.NewAlgoliaSource()
+	case "firebase":
+		hn.DefaultSource = hn.NewFirebaseSource()
+	case "firebase-lazy":
+		src := hn.NewFirebaseSource()
+		// Exports contain the whole thread, so replies are only fetched on demand in the interactive views.
+		src.Lazy = *format == ""
+		hn.DefaultSource = src
+	default:
+		fmt.Printf("Unknown source: %s\n", *source)
+		os.Exit(1)
+	}
+
+	// Algolia can not enumerate rankings, so lists fall back to the official API.
+	var lister hn.Lister = hn.NewFirebaseSource()
+	if l, ok := hn.DefaultSource.(hn.Lister); ok {
+		lister = l
+	}
+
+	// Lazily fetched threads are incomplete, so caching them would hide replies from later visits.
+	if *source == "firebase-lazy" {
+		if *offline {
+			fmt.Println("The firebase-lazy source can not be used offline")
+			os.Exit(1)
+		}
+	} else if dir, err := hn.DefaultCacheDir(); err == nil {
+		hn.DefaultSource = &hn.CachedSource{
+			Source:  hn.DefaultSource,
+			Cache:   &hn.Cache{Dir: dir},
+			MaxAge:  *maxAge,
+			Offline: *offline,
+		}
+	} else if *offline {
+		fmt.Printf("Could not determine cache directory: %s\n", err)
+		os.Exit(1)
+	}
+
+	arg := flag.Arg(0)
+	if list, ok := lists[arg]; ok {
+		// Rankings change constantly, so they are never cached.
+		if *offline {
+			fmt.Println("Story lists are not available offline")
+			os.Exit(1)
+		}
+		load := func(ctx context.Context) ([]*hn.Story, error) {
+			return lister.Stories(ctx, list, 100)
+		}
+		if err := app.Browse(arg, hn.DefaultSource, load, loadOptions()); err != nil {
+			fmt.Println("Error running program:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if arg == "search" {
+		// Search results are never cached.
+		if *offline {
+			fmt.Println("Search is not available offline")
+			os.Exit(1)
+		}
+		if err := search(flag.Args()[1:], loadOptions()); err != nil {
+			fmt.Println("Error running program:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	switch arg {
+	case "":
 		flag.Usage()
 		os.Exit(1)
-	} else if id, err = strconv.Atoi(os.Args[1]); err != nil {
+	case "-":
+		var input []byte
+		if input, err = io.ReadAll(os.Stdin); err == nil {
+			id, selected, err = hn.FindItemRef(string(input))
+		}
+	default:
+		id, selected, err = hn.ParseItemRef(arg)
+	}
+	if err != nil {
 		fmt.Printf("Could not parse id: %s\n", err)
 		os.Exit(1)
 	}
@@ -61,8 +200,192 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := app.Run(t); err != nil {
+	if *format != "" {
+		f, err := hn.ParseFormat(*format)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := hn.Export(os.Stdout, t, f); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing thread:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Comments are opened in the context of their story.
+	if t.StoryID != 0 && t.StoryID != t.Id {
+		selected = cmp.Or(selected, t.Id)
+		if t, err = hn.NewThread(t.StoryID); err != nil {
+			fmt.Printf("Error fetching thread: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := app.Run(t, selected, loadOptions()); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
 }
+
+// options builds the options of the interactive views from the configuration file at path, or the default location if
+// path is empty, and the given flags.
+func options(path string, ascii bool, width string, watch time.Duration) (app.Options, error) {
+	var opts app.Options
+	if path == "" {
+		path, _ = config.DefaultPath()
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return opts, err
+	}
+	keys, err := cfg.KeyMap()
+	if err != nil {
+		return opts, err
+	}
+	opts.Thread = append(opts.Thread, threadview.WithKeys(keys), threadview.WithRefreshInterval(watch))
+
+	theme, err := cfg.Theme()
+	if err != nil {
+		return opts, err
+	}
+	opts.Thread = append(opts.Thread, threadview.WithStatusStyle(hn.LipglossStyle(theme.StatusBar)))
+	opts.List = append(opts.List, storylist.WithStyles(storylist.Styles{
+		Title:    hn.LipglossStyle(theme.Title),
+		Metadata: hn.LipglossStyle(theme.Metadata),
+		Selected: hn.LipglossStyle(theme.Selection),
+		Status:   hn.LipglossStyle(theme.StatusBar),
+	}))
+
+	if !ascii {
+		if ascii, err = cfg.Bool("", "ascii", false); err != nil {
+			return opts, err
+		}
+	}
+	plain := ascii || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+	if plain {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		opts.List = append(opts.List, storylist.WithMarker("*"))
+	}
+	hyperlinks, err := cfg.Bool("", "hyperlinks", supportsHyperlinks())
+	if err != nil {
+		return opts, err
+	}
+	syntax, err := cfg.Bool("", "highlight_code", true)
+	if err != nil {
+		return opts, err
+	}
+	if width == "" {
+		// Fixed widths may be given as plain numbers.
+		if n, err := cfg.Int("", "width", 0); err == nil && n != 0 {
+			width = strconv.Itoa(n)
+		} else if width, err = cfg.String("", "width", ""); err != nil {
+			return opts, err
+		}
+	}
+	policy := hn.DefaultWidthPolicy
+	if width != "" {
+		if policy, err = hn.ParseWidthPolicy(width); err != nil {
+			return opts, err
+		}
+	}
+	maxIndent, err := cfg.Int("", "max_indent", hn.DefaultMaxIndent)
+	if err != nil {
+		return opts, err
+	}
+	rails, err := cfg.Bool("", "rails", true)
+	if err != nil {
+		return opts, err
+	}
+	depthColors, err := cfg.Bool("", "depth_colors", false)
+	if err != nil {
+		return opts, err
+	}
+	opts.Renderer = hn.NewRenderer(theme, plain,
+		hn.WithHyperlinks(hyperlinks),
+		hn.WithSyntaxHighlighting(syntax),
+		hn.WithWidthPolicy(policy),
+		hn.WithMaxIndent(maxIndent),
+		hn.WithRails(rails),
+		hn.WithDepthColors(depthColors),
+	)
+	return opts, nil
+}
+
+func search(args []string, opts app.Options) error {
+	var (
+		fs        = flag.NewFlagSet("search", flag.ExitOnError)
+		tags      = fs.String("tags", "", "Restrict results to either 'story' or 'comment'")
+		author    = fs.String("author", "", "Restrict results to items by the given user")
+		since     = fs.String("since", "", "Restrict results to items newer than the given duration (e.g 12h, 7d) or date (e.g 2006-01-02)")
+		minPoints = fs.Int("min-points", 0, "Restrict results to items with at least the given number of points")
+		byDate    = fs.Bool("by-date", false, "Sort results by date instead of relevance")
+	)
+	fs.Parse(args)
+
+	query := strings.Join(fs.Args(), " ")
+	if query == "" {
+		return errors.New("missing search query")
+	}
+	if *tags != "" && *tags != "story" && *tags != "comment" {
+		return fmt.Errorf("invalid tag: %s", *tags)
+	}
+	searchOpts := hn.SearchOptions{
+		Tags:        *tags,
+		Author:      *author,
+		MinPoints:   *minPoints,
+		ByDate:      *byDate,
+		HitsPerPage: 100,
+	}
+	if *since != "" {
+		t, err := parseSince(*since)
+		if err != nil {
+			return err
+		}
+		searchOpts.Since = t
+	}
+
+	load := func(ctx context.Context) ([]*hn.Story, error) {
+		return hn.NewAlgoliaSource().Search(ctx, query, searchOpts)
+	}
+	return app.Browse(query, hn.DefaultSource, load, opts)
+}
+
+// parseSince parses either a duration relative to now, with an additional day unit, or an absolute date.
+func parseSince(s string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", s)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// supportsHyperlinks guesses whether the terminal supports OSC 8 hyperlinks. There is no way to query this, so only
+// terminals known to support them are considered.
+func supportsHyperlinks() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("WT_SESSION") != "" || os.Getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	// VTE based terminals such as GNOME Terminal support hyperlinks since 0.50.
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.Contains(term, "foot") || strings.Contains(term, "alacritty")
+}
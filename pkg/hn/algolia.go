@@ -0,0 +1,35 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+)
+
+// AlgoliaBaseURL is the default API root of the Algolia HN backend.
+const AlgoliaBaseURL = "https://hn.algolia.com/api/v1"
+
+// AlgoliaSource fetches stories through the Algolia HN API, which returns an item's entire comment tree in a single request.
+type AlgoliaSource struct {
+	Client
+}
+
+func NewAlgoliaSource(opts ...ClientOption) *AlgoliaSource {
+	return &AlgoliaSource{newClient(AlgoliaBaseURL, opts...)}
+}
+
+func (s *AlgoliaSource) Name() string { return "algolia" }
+
+func (s *AlgoliaSource) Thread(ctx context.Context, id int) (*Story, error) {
+	body, err := s.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Decode(body)
+}
+
+// Fetch fetches the item with the given id along with its entire comment tree, as received.
+func (s *AlgoliaSource) Fetch(ctx context.Context, id int) ([]byte, error) {
+	return s.get(ctx, fmt.Sprintf("/items/%d", id))
+}
+
+func (s *AlgoliaSource) Decode(data []byte) (*Story, error) { return NewThreadFromData(data) }
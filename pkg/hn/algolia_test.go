@@ -0,0 +1,70 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// fixtureServer serves the given bodies by request path, responding with 404 to all other requests.
+func fixtureServer(t *testing.T, bodies map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAlgoliaThread(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{
+		"/items/1": `{
+			"id": 1, "author": "pg", "title": "Y Combinator", "url": "http://ycombinator.com", "points": 57,
+			"created_at": "2006-10-09T18:21:51.000Z", "text": null,
+			"children": [
+				{"id": 2, "author": "sama", "text": "<p>First", "parent_id": 1, "story_id": 1, "children": [
+					{"id": 4, "author": "pg", "text": "Reply", "parent_id": 2, "story_id": 1, "children": []}
+				]},
+				{"id": 3, "author": "jl", "text": "Second", "parent_id": 1, "story_id": 1, "children": []}
+			]
+		}`,
+	})
+	src := NewAlgoliaSource(WithBaseURL(srv.URL+"/"), WithHTTPClient(srv.Client()))
+
+	s, err := src.Thread(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Title != "Y Combinator" || s.Domain() != "ycombinator.com" || s.Points != 57 {
+		t.Errorf("unexpected story: %+v", s)
+	}
+	var ids []int
+	for _, c := range s.Comments() {
+		ids = append(ids, c.Id)
+	}
+	if want := []int{2, 4, 3}; !slices.Equal(ids, want) {
+		t.Errorf("got comments %v, want %v", ids, want)
+	}
+	if c := s.Children_[0].Children_[0]; c.parent != s.Children_[0] || c.Text() != "Reply" {
+		t.Errorf("reply not linked to its parent")
+	}
+
+	if _, err := src.Thread(context.Background(), 5); err == nil {
+		t.Error("expected an error for a missing item")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := src.Thread(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
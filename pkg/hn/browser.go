@@ -0,0 +1,65 @@
+package hn
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// OpenURL returns a command opening u in the user's browser, resulting in a `threadview.OpenLinkResultMsg`. The
+// browser is taken from `$BROWSER`, which may list several commands separated by colons of which the first installed
+// one is used. As it may be a terminal browser, it is run in the foreground with the program suspended until it exits.
+// Without any, the platform's default handler is started in the background.
+func OpenURL(u string) tea.Cmd {
+	for _, b := range strings.Split(os.Getenv("BROWSER"), ":") {
+		args := strings.Fields(b)
+		if len(args) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := browserCommand(args, u)
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return threadview.OpenLinkResultMsg{Error: err}
+		})
+	}
+
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		args = []string{"open"}
+	case "windows":
+		args = []string{"rundll32", "url.dll,FileProtocolHandler"}
+	default:
+		args = []string{"xdg-open"}
+	}
+	return func() tea.Msg {
+		cmd := browserCommand(args, u)
+		if err := cmd.Start(); err != nil {
+			return threadview.OpenLinkResultMsg{Error: err}
+		}
+		// The browser may outlive the program, so it is not waited on beyond reaping the process.
+		go cmd.Wait()
+		return threadview.OpenLinkResultMsg{}
+	}
+}
+
+// browserCommand builds the command opening u. Commands may contain a placeholder for the URL, otherwise it is passed
+// as the last argument.
+func browserCommand(args []string, u string) *exec.Cmd {
+	withURL := false
+	for i, a := range args {
+		if strings.Contains(a, "%s") {
+			args[i], withURL = strings.ReplaceAll(a, "%s", u), true
+		}
+	}
+	if !withURL {
+		args = append(args, u)
+	}
+	return exec.Command(args[0], args[1:]...)
+}
@@ -0,0 +1,127 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Cache stores fetched stories on disk as received from their source, keyed by source name and item ID. The time an
+// entry was fetched is that of its last modification.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCacheDir returns the directory used for caching threads, usually `$XDG_CACHE_HOME/hn`.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hn"), nil
+}
+
+func (c *Cache) path(source string, id int) string {
+	return filepath.Join(c.Dir, source, strconv.Itoa(id)+".json")
+}
+
+// Load returns the cached data of the given source for the story with the given id, along with the time it was fetched.
+func (c *Cache) Load(source string, id int) ([]byte, time.Time, error) {
+	path := c.path(source, id)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, info.ModTime(), nil
+}
+
+// Store writes the data of the given source for the story with the given id to the cache.
+func (c *Cache) Store(source string, id int, data []byte) error {
+	return writeFileAtomic(c.path(source, id), data)
+}
+
+// writeFileAtomic replaces the file at path with data, creating its directory if necessary. The file is replaced
+// atomically so that concurrent readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// CachedSource wraps another source, serving stories from the cache where possible and caching everything fetched.
+type CachedSource struct {
+	Source
+	Cache *Cache
+	// MaxAge is the age up to which cached entries are served without contacting the underlying source.
+	MaxAge time.Duration
+	// Offline disables the underlying source entirely; cached entries are served regardless of their age.
+	Offline bool
+}
+
+func (s *CachedSource) Thread(ctx context.Context, id int) (*Story, error) {
+	raw, ok := s.Source.(RawSource)
+	if !ok {
+		// Only data as received from the backend is cached.
+		if s.Offline {
+			return nil, fmt.Errorf("item %d is not available offline: source can not be cached", id)
+		}
+		return s.Source.Thread(ctx, id)
+	}
+	var cached *Story
+	data, fetchedAt, err := s.Cache.Load(raw.Name(), id)
+	if err == nil {
+		// Corrupt entries are treated as missing.
+		cached, err = raw.Decode(data)
+	}
+	if err == nil && (s.Offline || time.Since(fetchedAt) <= s.MaxAge) {
+		return cached, nil
+	}
+	if s.Offline {
+		return nil, fmt.Errorf("item %d is not available offline: %w", id, err)
+	}
+	t, err := s.fetch(ctx, raw, id)
+	if err != nil {
+		// A stale copy beats no copy at all.
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// fetch fetches the story from the underlying source and caches it.
+func (s *CachedSource) fetch(ctx context.Context, raw RawSource, id int) (*Story, error) {
+	data, err := raw.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := raw.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	// Caching is best-effort, failing to write the entry should not prevent the thread from being shown.
+	_ = s.Cache.Store(raw.Name(), id, data)
+	return t, nil
+}
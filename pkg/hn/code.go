@@ -0,0 +1,266 @@
+package hn
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// language describes the lexical syntax of a programming language, as far as needed for highlighting it.
+type language struct {
+	name          string
+	keywords      []string
+	lineComments  []string
+	blockComments [][2]string
+	quotes        string
+	// hints are snippets which are characteristic for the language, used to guess the language of a code block.
+	hints []string
+}
+
+var languages = []*language{
+	{
+		name: "go",
+		keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func", "go",
+			"goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch",
+			"type", "var", "nil", "true", "false",
+		},
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        "\"'`",
+		hints:         []string{"func ", ":= ", "package ", "err != nil", "fmt.", "chan ", "go func"},
+	},
+	{
+		name: "python",
+		keywords: []string{
+			"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else",
+			"except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not", "or",
+			"pass", "raise", "return", "try", "while", "with", "yield", "None", "True", "False", "self",
+		},
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		hints:        []string{"def ", "elif ", "self.", "import ", "print(", "None", "__init__", "):\n"},
+	},
+	{
+		name: "javascript",
+		keywords: []string{
+			"async", "await", "break", "case", "catch", "class", "const", "continue", "default", "delete", "do", "else",
+			"export", "extends", "finally", "for", "function", "if", "import", "in", "instanceof", "let", "new", "of",
+			"return", "switch", "this", "throw", "try", "typeof", "var", "void", "while", "yield", "null",
+			"undefined", "true", "false", "interface", "type",
+		},
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        "\"'`",
+		hints:         []string{"function", "const ", "let ", "=> ", "console.", "===", "require(", "document."},
+	},
+	{
+		name: "rust",
+		keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate", "else", "enum", "extern", "false", "fn",
+			"for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return", "self",
+			"Self", "static", "struct", "super", "trait", "true", "type", "unsafe", "use", "where", "while",
+		},
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        "\"",
+		hints:         []string{"fn ", "let mut ", "impl ", "::", "-> ", "&mut ", "println!", "unwrap()"},
+	},
+	{
+		name: "c",
+		keywords: []string{
+			"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum", "extern",
+			"float", "for", "goto", "if", "int", "long", "register", "return", "short", "signed", "sizeof", "static",
+			"struct", "switch", "typedef", "union", "unsigned", "void", "volatile", "while", "class", "public",
+			"private", "template", "namespace", "new", "delete", "NULL", "nullptr", "true", "false",
+		},
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        "\"'",
+		hints:         []string{"#include", "int main", "printf(", "std::", "->", "malloc(", "void "},
+	},
+	{
+		name: "shell",
+		keywords: []string{
+			"if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac", "in", "function",
+			"return", "export", "local", "echo", "sudo",
+		},
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		hints:        []string{"$ ", "#!/bin", "sudo ", "echo ", " | ", "apt ", "fi\n", "done\n", "${"},
+	},
+	{
+		name: "sql",
+		keywords: []string{
+			"SELECT", "FROM", "WHERE", "JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "ON", "GROUP", "BY", "ORDER",
+			"HAVING", "LIMIT", "INSERT", "INTO", "VALUES", "UPDATE", "SET", "DELETE", "CREATE", "TABLE", "INDEX",
+			"AND", "OR", "NOT", "NULL", "AS", "WITH", "DISTINCT", "UNION", "select", "from", "where", "join", "on",
+			"group", "by", "order", "and", "or", "not", "null", "as", "insert", "into", "values", "update", "set",
+		},
+		lineComments: []string{"--"},
+		quotes:       "'\"",
+		hints:        []string{"SELECT ", "FROM ", "WHERE ", "select ", " from ", "INSERT INTO", "CREATE TABLE"},
+	},
+}
+
+// guessLanguage returns the language whose hints occur most often in code, or nil if none of them does.
+func guessLanguage(code string) *language {
+	var (
+		best      *language
+		bestScore int
+	)
+	for _, l := range languages {
+		score := 0
+		for _, h := range l.hints {
+			score += strings.Count(code, h)
+		}
+		if score > bestScore {
+			best, bestScore = l, score
+		}
+	}
+	return best
+}
+
+// highlightCode splits code into lines of tokens. Keywords, strings, comments and numbers are typed accordingly,
+// everything else is raw text.
+func highlightCode(code string, lang *language) [][]TextBlock {
+	var (
+		lines [][]TextBlock
+		// The end of the block comment spanning the line break, if any.
+		inComment string
+	)
+	for _, line := range strings.Split(code, "\n") {
+		var tokens []TextBlock
+		emit := func(typ BlockType, s string) {
+			if n := len(tokens); n > 0 && tokens[n-1].Type == typ {
+				tokens[n-1].Text += s
+				return
+			}
+			tokens = append(tokens, TextBlock{Type: typ, Text: s})
+		}
+		for i := 0; i < len(line); {
+			rest := line[i:]
+			if inComment != "" {
+				j := strings.Index(rest, inComment)
+				if j < 0 {
+					emit(BlockTypeComment, rest)
+					break
+				}
+				j += len(inComment)
+				emit(BlockTypeComment, rest[:j])
+				inComment = ""
+				i += j
+				continue
+			}
+			if lang == nil {
+				emit(BlockTypeRaw, rest)
+				break
+			}
+			if hasAnyPrefix(rest, lang.lineComments) {
+				emit(BlockTypeComment, rest)
+				break
+			}
+			if c, ok := blockComment(rest, lang); ok {
+				inComment = c[1]
+				emit(BlockTypeComment, c[0])
+				i += len(c[0])
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(rest)
+			switch {
+			case strings.ContainsRune(lang.quotes, r):
+				j := stringEnd(rest, r)
+				emit(BlockTypeString, rest[:j])
+				i += j
+			// Only ASCII digits start numbers, such that tokens never split a rune.
+			case r >= '0' && r <= '9' && !(i > 0 && isIdentByte(line[i-1])):
+				j := 1
+				for j < len(rest) && (isIdentByte(rest[j]) || rest[j] == '.') {
+					j++
+				}
+				emit(BlockTypeNumber, rest[:j])
+				i += j
+			case r == '_' || unicode.IsLetter(r):
+				j := 0
+				for j < len(rest) && isIdentByte(rest[j]) {
+					j++
+				}
+				j = max(j, size)
+				if word := rest[:j]; slices.Contains(lang.keywords, word) {
+					emit(BlockTypeKeyword, word)
+				} else {
+					emit(BlockTypeRaw, word)
+				}
+				i += j
+			default:
+				emit(BlockTypeRaw, rest[:size])
+				i += size
+			}
+		}
+		lines = append(lines, tokens)
+	}
+	return lines
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func blockComment(s string, lang *language) ([2]string, bool) {
+	for _, c := range lang.blockComments {
+		if strings.HasPrefix(s, c[0]) {
+			return c, true
+		}
+	}
+	return [2]string{}, false
+}
+
+// stringEnd returns the offset just past the string literal starting at the beginning of s, or the length of s if the
+// literal is not terminated on this line.
+func stringEnd(s string, quote rune) int {
+	for i := 1; i < len(s); i++ {
+		switch rune(s[i]) {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop.
+func expandTabs(s string, width int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var (
+		b   strings.Builder
+		col int
+	)
+	for _, r := range s {
+		switch r {
+		case '\t':
+			n := width - col%width
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+	return b.String()
+}
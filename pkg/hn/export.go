@@ -0,0 +1,290 @@
+package hn
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/muesli/reflow/indent"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// Format is an output format supported by `Export`.
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// exportTextWidth is the column at which exported plain text is wrapped.
+const exportTextWidth = 80
+
+const exportDateFormat = "2006-01-02 15:04"
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatText, FormatJSON, FormatMarkdown, FormatHTML:
+		return f, nil
+	case "md":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unknown format: %s", s)
+}
+
+// Export writes the entire thread rooted at t to w in the given format.
+func Export(w io.Writer, t *Story, f Format) error {
+	var s string
+	switch f {
+	case FormatText:
+		s = exportText(t)
+	case FormatMarkdown:
+		s = exportMarkdown(t)
+	case FormatHTML:
+		s = exportHTML(t)
+	case FormatJSON:
+		b, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return err
+		}
+		s = string(b) + "\n"
+	default:
+		return fmt.Errorf("unknown format: %s", f)
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// storyMetadata returns the line summarizing a story's score, author, date and comment count.
+func storyMetadata(t *Story) string {
+	return fmt.Sprintf("%d points by %s on %s | %d comments",
+		t.Points,
+		t.Author,
+		formatDate(t.Date),
+		len(t.Comments()),
+	)
+}
+
+// commentAuthor returns the comment's author, or a marker if the comment was deleted.
+func commentAuthor(t *Story) string {
+	if t.Deleted {
+		return "[deleted]"
+	}
+	return t.Author
+}
+
+func formatDate(d time.Time) string {
+	return d.UTC().Format(exportDateFormat)
+}
+
+func exportText(t *Story) string {
+	var b strings.Builder
+	b.WriteString(t.Title + "\n")
+	if t.URL != nil {
+		b.WriteString(t.URL.String() + "\n")
+	}
+	b.WriteString(storyMetadata(t) + "\n")
+	if hasText(t.doc) {
+		b.WriteString(docToText(t.doc, exportTextWidth) + "\n")
+	}
+	dfs(nil, t, func(root, cur *Story) {
+		if cur == t {
+			return
+		}
+		depth := cur.depth() - 1
+		comment := fmt.Sprintf("%s (%s)\n%s\n",
+			commentAuthor(cur),
+			formatDate(cur.Date),
+			// Deep replies are still wrapped, if only at a narrow width.
+			docToText(cur.doc, max(exportTextWidth-depth*2, minTextWidth)),
+		)
+		b.WriteString("\n" + indent.String(comment, uint(depth*2)))
+	})
+	return b.String()
+}
+
+func hasText(doc *Node) bool {
+	return doc != nil && len(doc.Children) > 0
+}
+
+// docToText renders the document as plain text wrapped at width. Quotes are prefixed by "> " and code blocks are
+// kept verbatim.
+func docToText(doc *Node, width int) string {
+	if doc == nil {
+		return ""
+	}
+	var lines []string
+	for _, block := range doc.Children {
+		switch block.Kind {
+		case NodeParagraph:
+			lines = append(lines, wordwrap.String(block.PlainText(), width))
+		case NodeQuote:
+			for _, p := range block.Children {
+				for _, l := range strings.Split(wordwrap.String(p.PlainText(), width-2), "\n") {
+					lines = append(lines, "> "+l)
+				}
+			}
+		case NodeCodeBlock:
+			lines = append(lines, block.PlainText())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func exportMarkdown(t *Story) string {
+	var b strings.Builder
+	if t.URL != nil {
+		fmt.Fprintf(&b, "# [%s](%s)\n\n", t.Title, t.URL)
+	} else {
+		fmt.Fprintf(&b, "# %s\n\n", t.Title)
+	}
+	b.WriteString(storyMetadata(t) + "\n\n")
+	if hasText(t.doc) {
+		b.WriteString(docToMarkdown(t.doc) + "\n\n")
+	}
+	dfs(nil, t, func(root, cur *Story) {
+		if cur == t {
+			return
+		}
+		pad := strings.Repeat("  ", cur.depth()-1)
+		fmt.Fprintf(&b, "%s- **%s** (%s)\n\n", pad, commentAuthor(cur), formatDate(cur.Date))
+		for _, line := range strings.Split(docToMarkdown(cur.doc), "\n") {
+			if line == "" {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(pad + "  " + line + "\n")
+			}
+		}
+		b.WriteString("\n")
+	})
+	return b.String()
+}
+
+func docToMarkdown(doc *Node) string {
+	if doc == nil {
+		return ""
+	}
+	blocks := make([]string, 0, len(doc.Children))
+	for _, block := range doc.Children {
+		switch block.Kind {
+		case NodeParagraph:
+			blocks = append(blocks, inlineToMarkdown(block))
+		case NodeQuote:
+			paras := make([]string, len(block.Children))
+			for i, p := range block.Children {
+				paras[i] = "> " + strings.ReplaceAll(inlineToMarkdown(p), "\n", "\n> ")
+			}
+			blocks = append(blocks, strings.Join(paras, "\n>\n"))
+		case NodeCodeBlock:
+			code := strings.TrimRight(block.Text, "\n")
+			var lang string
+			if l := guessLanguage(code); l != nil {
+				lang = l.name
+			}
+			blocks = append(blocks, fmt.Sprintf("```%s\n%s\n```", lang, code))
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func inlineToMarkdown(n *Node) string {
+	var b strings.Builder
+	for _, c := range n.Children {
+		switch c.Kind {
+		case NodeText:
+			b.WriteString(c.Text)
+		case NodeLineBreak:
+			b.WriteString("  \n")
+		case NodeEmphasis:
+			fmt.Fprintf(&b, "*%s*", inlineToMarkdown(c))
+		case NodeLink:
+			// HN abbreviates the text of long links, in which case the target is given explicitly.
+			if text := c.PlainText(); c.Href == "" || text == c.Href {
+				fmt.Fprintf(&b, "<%s>", text)
+			} else {
+				fmt.Fprintf(&b, "[%s](%s)", inlineToMarkdown(c), c.Href)
+			}
+		}
+	}
+	return b.String()
+}
+
+func exportHTML(t *Story) string {
+	var b strings.Builder
+	title := html.EscapeString(t.Title)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", title)
+	if t.URL != nil {
+		fmt.Fprintf(&b, "<h1><a href=\"%s\">%s</a></h1>\n", html.EscapeString(t.URL.String()), title)
+	} else {
+		fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	}
+	fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(storyMetadata(t)))
+	if hasText(t.doc) {
+		fmt.Fprintf(&b, "<div>%s</div>\n", docToHTML(t.doc))
+	}
+	writeHTMLComments(&b, t)
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func writeHTMLComments(b *strings.Builder, t *Story) {
+	if len(t.Children_) == 0 {
+		return
+	}
+	b.WriteString("<ul>\n")
+	for _, c := range t.Children_ {
+		fmt.Fprintf(b, "<li id=\"%d\">\n<p><b>%s</b> (%s)</p>\n<div>%s</div>\n",
+			c.Id,
+			html.EscapeString(commentAuthor(c)),
+			formatDate(c.Date),
+			docToHTML(c.doc),
+		)
+		writeHTMLComments(b, c)
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+}
+
+// docToHTML renders the document as HTML. Unlike the markup served by HN, the output only ever contains the
+// elements represented by the document tree.
+func docToHTML(n *Node) string {
+	var b strings.Builder
+	writeHTMLNode(&b, n)
+	return b.String()
+}
+
+func writeHTMLNode(b *strings.Builder, n *Node) {
+	if n == nil {
+		return
+	}
+	var open, closing string
+	switch n.Kind {
+	case NodeParagraph:
+		open, closing = "<p>", "</p>"
+	case NodeQuote:
+		open, closing = "<blockquote>", "</blockquote>"
+	case NodeCodeBlock:
+		fmt.Fprintf(b, "<pre><code>%s</code></pre>", html.EscapeString(n.Text))
+		return
+	case NodeText:
+		b.WriteString(html.EscapeString(n.Text))
+		return
+	case NodeLineBreak:
+		b.WriteString("<br>")
+		return
+	case NodeEmphasis:
+		open, closing = "<i>", "</i>"
+	case NodeLink:
+		open, closing = fmt.Sprintf("<a href=\"%s\">", html.EscapeString(n.Href)), "</a>"
+	}
+	b.WriteString(open)
+	for _, c := range n.Children {
+		writeHTMLNode(b, c)
+	}
+	b.WriteString(closing)
+}
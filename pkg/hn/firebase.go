@@ -0,0 +1,302 @@
+package hn
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FirebaseBaseURL is the default API root of the official HN API.
+const FirebaseBaseURL = "https://hacker-news.firebaseio.com/v0"
+
+// FirebaseSource fetches stories through the official HN API. As the API only serves single items, the comment tree is
+// assembled by walking each item's `kids`, fetching items concurrently.
+type FirebaseSource struct {
+	Client
+	// Workers is the maximum number of item requests in flight at any time.
+	Workers int
+	// Lazy makes `Thread` only fetch the story and its top-level comments. Deeper replies are fetched on demand, see
+	// `Story.LoadChildren`.
+	Lazy bool
+}
+
+func NewFirebaseSource(opts ...ClientOption) *FirebaseSource {
+	return &FirebaseSource{
+		Client:  newClient(FirebaseBaseURL, opts...),
+		Workers: 16,
+	}
+}
+
+// firebaseItem mirrors the item schema of the official API.
+type firebaseItem struct {
+	Id          int    `json:"id"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Text        string `json:"text"`
+	Title       string `json:"title"`
+	Score       int    `json:"score"`
+	URL         string `json:"url"`
+	Kids        []int  `json:"kids"`
+	Dead        bool   `json:"dead"`
+	Deleted     bool   `json:"deleted"`
+	Descendants int    `json:"descendants"`
+	Parent      int    `json:"parent"`
+}
+
+// apply copies the item's fields onto t, leaving its children untouched.
+func (i *firebaseItem) apply(t *Story) {
+	t.Id = i.Id
+	t.Author = i.By
+	t.Date = time.Unix(i.Time, 0)
+	t.TextRaw = i.Text
+	t.Title = i.Title
+	t.Points = i.Score
+	t.Dead = i.Dead
+	t.Deleted = i.Deleted
+	t.Descendants = i.Descendants
+	t.ParentID = i.Parent
+	if u, err := url.Parse(i.URL); err == nil && u.Scheme != "" {
+		t.URL = u
+	}
+}
+
+// ErrItemUnavailable is returned for items which do not exist (anymore) or can not be decoded.
+var ErrItemUnavailable = errors.New("item unavailable")
+
+func (s *FirebaseSource) item(ctx context.Context, id int) (*firebaseItem, error) {
+	_, item, err := s.rawItem(ctx, id)
+	return item, err
+}
+
+// rawItem fetches the item with the given id, returning it both as received and decoded.
+func (s *FirebaseSource) rawItem(ctx context.Context, id int) ([]byte, *firebaseItem, error) {
+	body, err := s.get(ctx, fmt.Sprintf("/item/%d.json", id))
+	if err != nil {
+		return nil, nil, err
+	}
+	item := &firebaseItem{}
+	if err := json.Unmarshal(body, item); err != nil {
+		return nil, nil, fmt.Errorf("item %d: %w: %w", id, ErrItemUnavailable, err)
+	}
+	// Purged items are served as null.
+	if item.Id == 0 {
+		return nil, nil, fmt.Errorf("item %d: %w", id, ErrItemUnavailable)
+	}
+	return body, item, nil
+}
+
+func (s *FirebaseSource) Name() string { return "firebase" }
+
+func (s *FirebaseSource) Thread(ctx context.Context, id int) (*Story, error) {
+	if s.Lazy {
+		return s.lazyThread(ctx, id)
+	}
+	data, err := s.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Decode(data)
+}
+
+// Fetch fetches the item with the given id along with its descendants and ancestors, the latter determining the story
+// a comment belongs to. The result is a JSON array of the items as received, starting with the requested one.
+func (s *FirebaseSource) Fetch(ctx context.Context, id int) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errOnce sync.Once
+		err     error
+		sem     = make(chan struct{}, max(1, s.Workers))
+		bodies  [][]byte
+		parent  int
+	)
+	fail := func(e error) {
+		errOnce.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+
+	var fetch func(id int, root bool)
+	fetch = func(id int, root bool) {
+		defer wg.Done()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+			return
+		}
+		body, item, e := s.rawItem(ctx, id)
+		<-sem
+		if e != nil {
+			// A single vanished reply should not make the whole thread unavailable, so it is left out instead.
+			if root || !errors.Is(e, ErrItemUnavailable) {
+				fail(e)
+			}
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		if root {
+			parent = item.Parent
+		}
+		for _, kid := range item.Kids {
+			wg.Add(1)
+			go fetch(kid, false)
+		}
+	}
+
+	// The root is fetched before any of its descendants, so it comes first.
+	wg.Add(1)
+	fetch(id, true)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	for parent != 0 {
+		body, item, err := s.rawItem(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+		parent = item.Parent
+	}
+	return slices.Concat([]byte("["), bytes.Join(bodies, []byte(",")), []byte("]")), nil
+}
+
+// Decode assembles the thread from data returned by `Fetch`. Kids are listed in rank order, which is preserved.
+func (s *FirebaseSource) Decode(data []byte) (*Story, error) {
+	var bodies []json.RawMessage
+	if err := json.Unmarshal(data, &bodies); err != nil {
+		return nil, err
+	}
+	if len(bodies) == 0 {
+		return nil, errors.New("no items")
+	}
+	items := make(map[int]*firebaseItem, len(bodies))
+	var first *firebaseItem
+	for _, body := range bodies {
+		item := &firebaseItem{}
+		if err := json.Unmarshal(body, item); err != nil {
+			return nil, err
+		}
+		first = cmp.Or(first, item)
+		items[item.Id] = item
+	}
+
+	var build func(item *firebaseItem) *Story
+	build = func(item *firebaseItem) *Story {
+		t := &Story{}
+		item.apply(t)
+		for _, kid := range item.Kids {
+			// Replies which could not be fetched are left out.
+			if c, ok := items[kid]; ok {
+				t.Children_ = append(t.Children_, build(c))
+			}
+		}
+		return t
+	}
+	root := build(first)
+	for parent := first.Parent; parent != 0; {
+		item, ok := items[parent]
+		if !ok {
+			break
+		}
+		root.StoryID, parent = item.Id, item.Parent
+	}
+	initNodes(root)
+	return root, nil
+}
+
+// storyID returns the ID of the story the comment t belongs to, found by walking up its ancestors, or 0 if t is a
+// story. Unlike Algolia, the API does not provide it directly.
+func (s *FirebaseSource) storyID(ctx context.Context, t *Story) (int, error) {
+	id := 0
+	for parent := t.ParentID; parent != 0; {
+		item, err := s.item(ctx, parent)
+		if err != nil {
+			return 0, err
+		}
+		id, parent = item.Id, item.Parent
+	}
+	return id, nil
+}
+
+func (s *FirebaseSource) lazyThread(ctx context.Context, id int) (*Story, error) {
+	item, err := s.item(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	root := &Story{}
+	item.apply(root)
+	if root.Children_, err = s.children(ctx, item.Kids); err != nil {
+		return nil, err
+	}
+	if root.StoryID, err = s.storyID(ctx, root); err != nil {
+		return nil, err
+	}
+	initNodes(root)
+	return root, nil
+}
+
+// Stories fetches the first n stories of the given ranking. Only the stories themselves are fetched, not their comments.
+func (s *FirebaseSource) Stories(ctx context.Context, list List, n int) ([]*Story, error) {
+	body, err := s.get(ctx, fmt.Sprintf("/%sstories.json", list))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, err
+	}
+	ids = ids[:min(n, len(ids))]
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, max(1, s.Workers))
+		res  = make([]*Story, len(ids))
+		errs = make([]error, len(ids))
+	)
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			item, err := s.item(ctx, id)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			t := &Story{}
+			item.apply(t)
+			initNodes(t)
+			res[i] = t
+		}()
+	}
+	wg.Wait()
+	// Stories may be deleted between fetching the list and fetching the items, so individual failures are skipped.
+	stories := res[:0]
+	for i, t := range res {
+		if t != nil {
+			stories = append(stories, t)
+		} else if err == nil {
+			err = errs[i]
+		}
+	}
+	if len(stories) == 0 && err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
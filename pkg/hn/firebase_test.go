@@ -0,0 +1,87 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var firebaseFixture = map[string]string{
+	"/item/1.json": `{"id": 1, "type": "story", "by": "pg", "title": "Y Combinator", "url": "http://ycombinator.com", "score": 57, "time": 1160418111, "kids": [2, 3, 6], "descendants": 3}`,
+	"/item/2.json": `{"id": 2, "type": "comment", "by": "sama", "text": "First", "parent": 1, "time": 1160418200, "kids": [4, 5]}`,
+	"/item/3.json": `{"id": 3, "type": "comment", "by": "jl", "text": "Second", "parent": 1, "time": 1160418300}`,
+	"/item/4.json": `{"id": 4, "type": "comment", "by": "pg", "text": "Reply", "parent": 2, "time": 1160418400}`,
+	// Purged items are served as null.
+	"/item/5.json": `null`,
+	"/item/6.json": `{"id": 6, "type": "comment", "deleted": true, "parent": 1, "time": 1160418500}`,
+}
+
+func TestFirebaseThread(t *testing.T) {
+	srv := fixtureServer(t, firebaseFixture)
+	src := NewFirebaseSource(WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+
+	s, err := src.Thread(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Title != "Y Combinator" || s.Author != "pg" || s.Points != 57 || s.StoryID != 0 {
+		t.Errorf("unexpected story: %+v", s)
+	}
+	// Kids keep their rank order and unavailable ones are left out.
+	if got, want := commentIDs(s), []int{2, 4, 3, 6}; !slices.Equal(got, want) {
+		t.Errorf("got comments %v, want %v", got, want)
+	}
+	if c := s.Children_[2]; !c.Deleted {
+		t.Errorf("comment %d is not marked as deleted", c.Id)
+	}
+
+	// Comments are fetched along with the story they belong to.
+	c, err := src.Thread(context.Background(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.StoryID != 1 || c.ParentID != 2 {
+		t.Errorf("got story %d and parent %d, want 1 and 2", c.StoryID, c.ParentID)
+	}
+
+	if _, err := src.Thread(context.Background(), 5); !errors.Is(err, ErrItemUnavailable) {
+		t.Errorf("got %v, want %v", err, ErrItemUnavailable)
+	}
+}
+
+func TestFirebaseThreadError(t *testing.T) {
+	fixture := map[string]string{
+		"/item/1.json": firebaseFixture["/item/1.json"],
+		"/item/2.json": firebaseFixture["/item/2.json"],
+	}
+	srv := fixtureServer(t, fixture)
+	src := NewFirebaseSource(WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+
+	// Unlike unavailable items, failing requests make the whole thread fail.
+	if _, err := src.Thread(context.Background(), 1); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestFirebaseLazyThread(t *testing.T) {
+	srv := fixtureServer(t, firebaseFixture)
+	src := NewFirebaseSource(WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+	src.Lazy = true
+
+	s, err := src.Thread(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := commentIDs(s), []int{2, 3, 6}; !slices.Equal(got, want) {
+		t.Errorf("got comments %v, want %v", got, want)
+	}
+	c := s.Children_[0]
+	if c.Loaded() || c.NumChildren() != 2 {
+		t.Fatalf("got loaded %v with %d replies, want false with 2", c.Loaded(), c.NumChildren())
+	}
+	c.Update(c.LoadChildren()())
+	if got, want := commentIDs(c), []int{4}; !c.Loaded() || !slices.Equal(got, want) {
+		t.Errorf("got replies %v, want %v", got, want)
+	}
+}
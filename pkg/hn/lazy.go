@@ -0,0 +1,142 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// pendingChildren refers to the replies of a story which have not been fetched yet.
+type pendingChildren struct {
+	src  *FirebaseSource
+	kids []int
+}
+
+// NumChildren returns the number of direct replies, including those which have not been loaded yet.
+func (t *Story) NumChildren() int {
+	if t.pending != nil {
+		return len(t.pending.kids)
+	}
+	return len(t.Children_)
+}
+
+// Loaded reports whether all replies have been loaded. Only threads fetched lazily may have replies which have not been
+// loaded, see `FirebaseSource.Lazy`.
+func (t *Story) Loaded() bool { return t.pending == nil }
+
+// LoadChildren returns a command fetching the direct replies which have not been loaded yet. The replies are added
+// once the resulting `threadview.ChildrenLoadedMsg` is passed to `Update`.
+func (t *Story) LoadChildren() tea.Cmd {
+	p, id := t.pending, t.Id
+	if p == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		children, err := p.src.children(context.Background(), p.kids)
+		msg := threadview.ChildrenLoadedMsg{ID: id, Err: err}
+		for _, c := range children {
+			msg.Children = append(msg.Children, c)
+		}
+		return msg
+	}
+}
+
+// adopt attaches the loaded replies to t. If the thread's visits are tracked, the returned command records the replies
+// as seen, see `ReadState.Track`.
+func (t *Story) adopt(children []threadview.Thread) tea.Cmd {
+	var adopted []*Story
+	for _, c := range children {
+		if s, ok := c.(*Story); ok {
+			initNodes(s)
+			adopted = append(adopted, s)
+		}
+	}
+	t.SetChildren(append(t.Children(), children...))
+	t.pending = nil
+
+	root := t
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.tracker == nil {
+		return nil
+	}
+	return root.tracker.adopted(root, adopted)
+}
+
+// expand fetches the pending replies of all comments of t whose IDs are in loaded, recursively.
+func (t *Story) expand(ctx context.Context, loaded map[int]bool) error {
+	if p := t.pending; p != nil {
+		if !loaded[t.Id] {
+			return nil
+		}
+		children, err := p.src.children(ctx, p.kids)
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			initNodes(c)
+			c.parent = t
+		}
+		t.Children_, t.pending = children, nil
+	}
+	for _, c := range t.Children_ {
+		if err := c.expand(ctx, loaded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numDescendants returns the number of replies below t, including those which have not been loaded yet as far as
+// known.
+func (t *Story) numDescendants() int {
+	n := threadview.NumNodes(t) - 1
+	if t.pending != nil {
+		n += len(t.pending.kids)
+	}
+	return max(n, t.Descendants)
+}
+
+// children fetches the items with the given IDs concurrently. The replies of the items are not fetched, but left
+// pending instead. Items which are unavailable are left out.
+func (s *FirebaseSource) children(ctx context.Context, ids []int) ([]*Story, error) {
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, max(1, s.Workers))
+		res  = make([]*Story, len(ids))
+		errs = make([]error, len(ids))
+	)
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			item, err := s.item(ctx, id)
+			if err != nil {
+				if !errors.Is(err, ErrItemUnavailable) {
+					errs[i] = err
+				}
+				return
+			}
+			t := &Story{}
+			item.apply(t)
+			if len(item.Kids) > 0 {
+				t.pending = &pendingChildren{s, item.Kids}
+			}
+			res[i] = t
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.DeleteFunc(res, func(t *Story) bool { return t == nil }), nil
+}
@@ -0,0 +1,131 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// Merge updates t with the contents of u, a more recent copy of the same thread. Comments only present in u are added
+// next to their preceding sibling and marked as unread, comments which are missing from u are kept. Comments which u
+// lists under a different parent are moved there.
+func (t *Story) Merge(u *Story) {
+	parents := map[int]int{}
+	dfs(nil, u, func(root, cur *Story) {
+		if root != nil {
+			parents[cur.Id] = root.Id
+		}
+	})
+	// Moved comments are detached up front, such that they are never part of the tree twice.
+	moved := map[int]*Story{}
+	dfs(nil, t, func(_, cur *Story) {
+		cur.Children_ = slices.DeleteFunc(cur.Children_, func(c *Story) bool {
+			if p, ok := parents[c.Id]; ok && p != cur.Id {
+				moved[c.Id] = c
+				return true
+			}
+			return false
+		})
+	})
+	t.merge(u, moved)
+}
+
+func (t *Story) merge(u *Story, moved map[int]*Story) {
+	t.Title, t.Points, t.URL = u.Title, u.Points, u.URL
+	t.Dead, t.Deleted, t.Descendants = u.Dead, u.Deleted, u.Descendants
+	if t.TextRaw != u.TextRaw {
+		t.TextRaw = u.TextRaw
+		t.doc, t.diagnostics = parseMarkup(t.TextRaw)
+	}
+	// Replies which have not been loaded can not be compared, see `FirebaseSource.Lazy`. Replies loaded in t are also
+	// loaded in u by `Refresh`, so only replies which are yet to be loaded anyway are skipped here.
+	if t.pending != nil || u.pending != nil {
+		if t.pending != nil && u.pending != nil {
+			t.pending = u.pending
+		}
+		return
+	}
+
+	old := make(map[int]*Story, len(t.Children_))
+	for _, c := range t.Children_ {
+		old[c.Id] = c
+	}
+	children := slices.Clone(t.Children_)
+	pos := 0
+	for _, c := range u.Children_ {
+		if o, ok := old[c.Id]; ok {
+			o.merge(c, moved)
+			pos = slices.Index(children, o) + 1
+			continue
+		}
+		if o, ok := moved[c.Id]; ok {
+			delete(moved, c.Id)
+			o.merge(c, moved)
+			o.parent = t
+			children = slices.Insert(children, pos, o)
+			pos++
+			continue
+		}
+		dfs(nil, c, func(_, cur *Story) {
+			// Comments moved below a new comment were read before.
+			if o, ok := moved[cur.Id]; ok {
+				cur.unread = o.unread
+				delete(moved, cur.Id)
+			} else {
+				cur.unread = true
+			}
+		})
+		c.parent = t
+		c.SetRenderer(t.render)
+		children = slices.Insert(children, pos, c)
+		pos++
+	}
+	t.Children_ = children
+}
+
+// Refresh returns a command fetching a more recent copy of the thread from `DefaultSource`, bypassing the cache. For
+// lazily fetched threads, the replies of all comments which have been expanded are fetched as well.
+func (t *Story) Refresh() tea.Cmd {
+	id, src := t.Id, DefaultSource
+	// The thread may change while the command runs, so the expanded comments are determined up front.
+	loaded := map[int]bool{}
+	dfs(nil, t, func(_, cur *Story) {
+		if cur.pending == nil {
+			loaded[cur.Id] = true
+		}
+	})
+	return func() tea.Msg {
+		var (
+			u   *Story
+			err error
+		)
+		if c, ok := src.(*CachedSource); ok {
+			u, err = c.Refresh(context.Background(), id)
+		} else {
+			u, err = src.Thread(context.Background(), id)
+		}
+		if err == nil {
+			err = u.expand(context.Background(), loaded)
+		}
+		msg := threadview.RefreshedMsg{ID: id, Err: err}
+		if err == nil {
+			msg.Thread = u
+		}
+		return msg
+	}
+}
+
+// Refresh fetches the story with the given id from the underlying source regardless of the age of the cached entry,
+// updating the cache.
+func (s *CachedSource) Refresh(ctx context.Context, id int) (*Story, error) {
+	if s.Offline {
+		return nil, errors.New("refreshing is not possible offline")
+	}
+	if raw, ok := s.Source.(RawSource); ok {
+		return s.fetch(ctx, raw, id)
+	}
+	return s.Source.Thread(ctx, id)
+}
@@ -0,0 +1,70 @@
+package hn
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// story builds a thread from the given ID and replies.
+func story(id int, children ...*Story) *Story {
+	t := &Story{Id: id, Author: "user", Children_: children}
+	initNodes(t)
+	return t
+}
+
+func TestMergeMovedComment(t *testing.T) {
+	// Comment 5 is moved from below comment 2 to the top level.
+	old := story(1, story(2, story(5)), story(3))
+	u := story(1, story(2), story(3), story(5))
+
+	m, err := threadview.New(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.View()
+	moved := old.Children_[0].Children_[0]
+	m.Update(threadview.RefreshedMsg{ID: 1, Thread: u})
+	m.View()
+
+	var ids []int
+	dfs(nil, old, func(_, cur *Story) { ids = append(ids, cur.Id) })
+	if want := []int{1, 2, 3, 5}; !slices.Equal(ids, want) {
+		t.Fatalf("got comments %v, want %v", ids, want)
+	}
+	if c := old.Children_[2]; c != moved || c.parent != old {
+		t.Errorf("moved comment was not reused")
+	}
+	if moved.unread {
+		t.Errorf("moved comment is marked as unread")
+	}
+}
+
+func TestMergeNewComment(t *testing.T) {
+	old := story(1, story(2), story(4))
+	u := story(1, story(2, story(5)), story(3), story(4))
+	old.Merge(u)
+
+	var ids []int
+	dfs(nil, old, func(_, cur *Story) {
+		ids = append(ids, cur.Id)
+		if want := cur.Id == 3 || cur.Id == 5; cur.unread != want {
+			t.Errorf("comment %d: got unread %v, want %v", cur.Id, cur.unread, want)
+		}
+	})
+	if want := []int{1, 2, 5, 3, 4}; !slices.Equal(ids, want) {
+		t.Fatalf("got comments %v, want %v", ids, want)
+	}
+}
+
+func TestMergeKeepsMissingComment(t *testing.T) {
+	old := story(1, story(2), story(3))
+	u := story(1, story(3))
+	old.Merge(u)
+	if n := len(old.Children_); n != 2 {
+		t.Errorf("got %d comments, want 2", n)
+	}
+}
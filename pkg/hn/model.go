@@ -1,14 +1,13 @@
 package hn
 
 import (
+	"cmp"
 	"fmt"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/fatih/color"
 	"github.com/mergestat/timediff"
 	"github.com/toalaah/hn/pkg/threadview"
 )
@@ -21,11 +20,25 @@ type Story struct {
 	Title     string    `json:"title,omitempty"`
 	Points    int       `json:"points"`
 	URL       *url.URL  `json:"url"`
-	Children_ []Story   `json:"children"`
+	Children_ []*Story  `json:"children"`
 	parent    *Story    `json:"-"`
 
-	textParts []TextBlock
-	state     State
+	// The following fields are only provided by some sources.
+	Dead        bool `json:"dead,omitempty"`
+	Deleted     bool `json:"deleted,omitempty"`
+	Descendants int  `json:"descendants,omitempty"`
+	// For comments, the IDs of the story the comment belongs to and of the item it replies to.
+	StoryID    int    `json:"story_id,omitempty"`
+	ParentID   int    `json:"parent_id,omitempty"`
+	StoryTitle string `json:"story_title,omitempty"`
+
+	doc         *Node
+	diagnostics []error
+	state       State
+	unread      bool
+	render      *Renderer
+	pending     *pendingChildren
+	tracker     *tracker
 }
 
 type State struct {
@@ -33,57 +46,116 @@ type State struct {
 	TextWidth int
 }
 
+// SetRenderer sets the renderer used by t and all of its descendants.
+func (t *Story) SetRenderer(r *Renderer) {
+	dfs(nil, t, func(root, cur *Story) {
+		cur.render = r
+	})
+}
+
+func (t *Story) renderer() *Renderer {
+	return cmp.Or(t.render, DefaultRenderer)
+}
+
 func (t *Story) Init() tea.Cmd { return nil }
 func (t *Story) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case threadview.DisplayStateMsg:
-		t.state = State{msg, 72}
+		t.state = State{DisplayStateMsg: msg}
 		// Direct replies should not be indented.
 		t.state.Depth = max(0, t.state.Depth-1)
+		t.state.SubthreadDepth--
+		t.state.TextWidth = t.renderer().TextWidth(msg.Width, t.state.Depth)
 	case threadview.CopyTextMsg:
 		cmds = append(cmds, func() tea.Msg {
-			err := clipboard.WriteAll(t.Text())
+			err := clipboard.WriteAll(cmp.Or(msg.Text, t.Text()))
 			return threadview.CopyTextResultMsg{Error: err}
 		})
+	case threadview.RefreshedMsg:
+		if u, ok := msg.Thread.(*Story); ok && msg.ID == t.Id && msg.Err == nil {
+			t.Merge(u)
+		}
+	case threadview.ChildrenLoadedMsg:
+		if msg.ID == t.Id && msg.Err == nil && t.pending != nil {
+			cmds = append(cmds, t.adopt(msg.Children))
+		}
+	case threadview.OpenLinkMsg:
+		cmds = append(cmds, OpenURL(msg.URL))
 	}
 	return t, tea.Batch(cmds...)
 }
 
 func (t *Story) CommentView() string {
-	var b strings.Builder
+	var blocks TextBlocks
 	// Header
 	{
 		relDate := fmt.Sprintf("(%s)", timediff.TimeDiff(t.Date))
 		numComments := "[-]"
 		if t.state.Collapsed {
-			numComments = fmt.Sprintf("[%d more]", threadview.NumNodes(t))
+			numComments = fmt.Sprintf("[%d more]", t.numDescendants()+1)
+		}
+		author := t.Author
+		if t.Deleted {
+			author = "[deleted]"
+		}
+		var header TextBlocks
+		if t.unread {
+			header = append(header, TextBlocks{
+				{Type: BlockTypeNew, Text: "[new]"},
+				{Type: BlockTypeText, Text: " "},
+			}...)
 		}
-		header := TextBlocks{
-			{Type: BlockTypeAuthor, Text: t.Author},
+		header = append(header, TextBlocks{
+			{Type: BlockTypeAuthor, Text: author},
 			{Type: BlockTypeText, Text: " "},
 			{Type: BlockTypeMetadata, Text: relDate},
 			{Type: BlockTypeText, Text: " "},
-			{Type: BlockTypeMetadata, Text: numComments},
-			{Type: BlockTypeText, Text: "\n"},
-		}
-		// Trim newline if comment is collapsed
-		if t.state.Collapsed {
-			header = header[:len(header)-1]
+		}...)
+		if t.Dead {
+			header = append(header, TextBlocks{
+				{Type: BlockTypeMetadata, Text: "[dead]"},
+				{Type: BlockTypeText, Text: " "},
+			}...)
 		}
-		b.WriteString(header.Render(t.state))
+		header = append(header, TextBlock{Type: BlockTypeMetadata, Text: numComments})
+		blocks = header
 	}
 	// Comment text
+	var doc *Node
 	if !t.state.Collapsed {
-		b.WriteString(TextBlocks(t.textParts).Render(t.state))
+		doc = t.doc
+	}
+	return t.renderer().Render(blocks, doc, t.state)
+}
+
+// StoryView renders the story itself, i.e its title, link, score and text.
+func (t *Story) StoryView() string {
+	header := TextBlocks{{Type: BlockTypeTitle, Text: t.Title}}
+	if d := t.Domain(); d != "" {
+		header = append(header, TextBlock{Type: BlockTypeMetadata, Text: fmt.Sprintf(" (%s)", d)})
+	}
+	header = append(header, TextBlock{Type: BlockTypeText, Text: "\n"})
+	if t.URL != nil {
+		header = append(header, TextBlocks{
+			{Type: BlockTypeLink, Text: t.URL.String(), Href: t.URL.String()},
+			{Type: BlockTypeText, Text: "\n"},
+		}...)
 	}
-	return b.String()
+	meta := fmt.Sprintf("%d points by %s %s | %d comments",
+		t.Points,
+		t.Author,
+		timediff.TimeDiff(t.Date),
+		t.numDescendants(),
+	)
+	header = append(header, TextBlock{Type: BlockTypeMetadata, Text: meta})
+	// Self-posts such as "Ask HN" carry their own text. The trailing newline separates the story from its comments.
+	return t.renderer().Render(header, t.doc, t.state) + "\n"
 }
 
 func (t *Story) View() string {
-	defer color.Unset()
 	if t.parent == nil {
-		return ""
+		return t.StoryView()
 	}
 	return t.CommentView()
 }
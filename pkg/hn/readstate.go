@@ -0,0 +1,135 @@
+package hn
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReadState persists, per story, which comments have been seen on the previous visit.
+type ReadState struct {
+	Dir string
+}
+
+// Visit records the comments present in a story when it was last opened.
+type Visit struct {
+	Time time.Time `json:"time"`
+	Seen []int     `json:"seen"`
+}
+
+// DefaultStateDir returns the directory used for persisting read state, usually `$XDG_STATE_HOME/hn`.
+func DefaultStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "hn"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "hn"), nil
+}
+
+func (r *ReadState) path(id int) string {
+	return filepath.Join(r.Dir, "read", strconv.Itoa(id)+".json")
+}
+
+// Load returns the last recorded visit of the story with the given id. If the story was never visited, an error
+// satisfying `errors.Is(err, os.ErrNotExist)` is returned.
+func (r *ReadState) Load(id int) (*Visit, error) {
+	body, err := os.ReadFile(r.path(id))
+	if err != nil {
+		return nil, err
+	}
+	v := &Visit{}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Save records a visit of t at the current time, marking all of its comments as seen in addition to those seen on earlier visits.
+func (r *ReadState) Save(t *Story) error {
+	return r.save(t.Id, commentIDs(t))
+}
+
+func (r *ReadState) save(id int, seen []int) error {
+	// Lazily fetched threads only contain the comments which have been loaded, so earlier visits are kept.
+	v := Visit{Time: time.Now(), Seen: seen}
+	if prev, err := r.Load(id); err == nil {
+		v.Seen = append(v.Seen, prev.Seen...)
+	}
+	slices.Sort(v.Seen)
+	v.Seen = slices.Compact(v.Seen)
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path(id), body)
+}
+
+// Track marks the comments of t which were not seen on the previous visit as unread and records the current visit.
+// Replies which are loaded later on, see `Story.LoadChildren`, are marked and recorded once they are added to t.
+func (r *ReadState) Track(t *Story) error {
+	tr := &tracker{state: r}
+	if v, err := r.Load(t.Id); err == nil {
+		v.MarkUnread(t)
+		tr.prev = v
+	}
+	t.tracker = tr
+	return r.Save(t)
+}
+
+// tracker records the replies of a thread which are loaded after it was opened, see `ReadState.Track`.
+type tracker struct {
+	state *ReadState
+	// The previous visit, if any.
+	prev *Visit
+}
+
+// adopted marks the given replies, which have just been loaded, and returns a command recording them as seen.
+func (tr *tracker) adopted(root *Story, children []*Story) tea.Cmd {
+	if tr.prev != nil {
+		for _, c := range children {
+			tr.prev.markUnread(append([]*Story{c}, c.Comments()...))
+		}
+	}
+	// The IDs are collected up front, as the thread may change while the command runs.
+	id, seen, rs := root.Id, commentIDs(root), tr.state
+	return func() tea.Msg {
+		_ = rs.save(id, seen)
+		return nil
+	}
+}
+
+// MarkUnread flags all comments of t which were not seen during the visit as unread and returns their number.
+func (v *Visit) MarkUnread(t *Story) int {
+	return v.markUnread(t.Comments())
+}
+
+func (v *Visit) markUnread(comments []*Story) int {
+	seen := make(map[int]bool, len(v.Seen))
+	for _, id := range v.Seen {
+		seen[id] = true
+	}
+	n := 0
+	for _, c := range comments {
+		c.unread = !seen[c.Id]
+		if c.unread {
+			n++
+		}
+	}
+	return n
+}
+
+func commentIDs(t *Story) []int {
+	var ids []int
+	for _, c := range t.Comments() {
+		ids = append(ids, c.Id)
+	}
+	return ids
+}
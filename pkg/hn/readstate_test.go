@@ -0,0 +1,51 @@
+package hn
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+func TestReadStateLazyThread(t *testing.T) {
+	rs := &ReadState{Dir: t.TempDir()}
+
+	// The first visit loads comment 3 and its reply 4.
+	if err := rs.Save(story(1, story(2), story(3, story(4)))); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second visit only loads the top-level comments at first.
+	s := story(1, story(2), story(3))
+	s.Children_[1].pending = &pendingChildren{}
+	if err := rs.Track(s); err != nil {
+		t.Fatal(err)
+	}
+	v, err := rs.Load(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 3, 4}; !slices.Equal(v.Seen, want) {
+		t.Errorf("got seen comments %v, want %v", v.Seen, want)
+	}
+	if v.Time.IsZero() {
+		t.Error("visit time was not recorded")
+	}
+
+	// Replies loaded later on are compared against the previous visit and recorded.
+	seen, added := story(4), story(5)
+	cmd := s.Children_[1].adopt([]threadview.Thread{seen, added})
+	if seen.unread || !added.unread {
+		t.Errorf("got unread %v and %v, want false and true", seen.unread, added.unread)
+	}
+	if cmd == nil {
+		t.Fatal("expected a command recording the replies")
+	}
+	cmd()
+	if v, err = rs.Load(1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 3, 4, 5}; !slices.Equal(v.Seen, want) {
+		t.Errorf("got seen comments %v, want %v", v.Seen, want)
+	}
+}
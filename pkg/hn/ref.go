@@ -0,0 +1,63 @@
+package hn
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// ParseItemRef parses a reference to an item, given either as its ID or as a link to it on news.ycombinator.com or
+// hn.algolia.com. Links pointing at a comment within a thread, such as the "context" links of news.ycombinator.com,
+// additionally yield the ID of the comment as selected.
+func ParseItemRef(s string) (id, selected int, err error) {
+	s = strings.TrimSpace(s)
+	if id, err := strconv.Atoi(s); err == nil && id > 0 {
+		return id, 0, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return 0, 0, fmt.Errorf("not an item ID or link: %s", s)
+	}
+	q := u.Query()
+	switch strings.TrimPrefix(u.Hostname(), "www.") {
+	case "news.ycombinator.com":
+		if u.Path == "/item" {
+			id, _ = strconv.Atoi(q.Get("id"))
+			selected, _ = strconv.Atoi(u.Fragment)
+		}
+	case "hn.algolia.com":
+		// Items are linked either through the API, e.g /api/v1/items/1, or as the story of a search.
+		if rest, ok := strings.CutPrefix(u.Path, "/api/v1/items/"); ok {
+			id, _ = strconv.Atoi(strings.Trim(rest, "/"))
+		} else if rest, ok := strings.CutPrefix(u.Path, "/story/"); ok {
+			id, _ = strconv.Atoi(strings.SplitN(rest, "/", 2)[0])
+		} else {
+			id, _ = strconv.Atoi(q.Get("story"))
+		}
+	}
+	if id <= 0 {
+		return 0, 0, fmt.Errorf("not a link to an item: %s", s)
+	}
+	if selected == id {
+		selected = 0
+	}
+	return id, selected, nil
+}
+
+// FindItemRef returns the first reference to an item within text, such as an article piped in by a feed reader. Text
+// consisting of a single reference may also be a plain ID, otherwise only links are considered.
+func FindItemRef(text string) (id, selected int, err error) {
+	if id, selected, err := ParseItemRef(text); err == nil {
+		return id, selected, nil
+	}
+	for _, s := range urlPattern.FindAllString(text, -1) {
+		if id, selected, err := ParseItemRef(strings.TrimRight(s, ".,;:)]")); err == nil {
+			return id, selected, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("no link to an item found")
+}
@@ -0,0 +1,70 @@
+package hn
+
+import "testing"
+
+func TestParseItemRef(t *testing.T) {
+	tests := []struct {
+		in           string
+		id, selected int
+		err          bool
+	}{
+		{in: "8863", id: 8863},
+		{in: " 8863\n", id: 8863},
+		{in: "https://news.ycombinator.com/item?id=8863", id: 8863},
+		{in: "http://www.news.ycombinator.com/item?id=8863", id: 8863},
+		{in: "https://news.ycombinator.com/item?id=8863#9224", id: 8863, selected: 9224},
+		{in: "https://news.ycombinator.com/item?id=8863#8863", id: 8863},
+		{in: "https://hn.algolia.com/api/v1/items/8863", id: 8863},
+		{in: "https://hn.algolia.com/story/8863/my-yc-app", id: 8863},
+		{in: "https://hn.algolia.com/?story=8863", id: 8863},
+		{in: "0", err: true},
+		{in: "-1", err: true},
+		{in: "abc", err: true},
+		{in: "", err: true},
+		{in: "news.ycombinator.com/item?id=8863", err: true},
+		{in: "ftp://news.ycombinator.com/item?id=8863", err: true},
+		{in: "https://news.ycombinator.com/user?id=pg", err: true},
+		{in: "https://news.ycombinator.com/item?id=abc", err: true},
+		{in: "https://example.com/item?id=8863", err: true},
+	}
+	for _, tt := range tests {
+		id, selected, err := ParseItemRef(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseItemRef(%q): expected an error, got %d, %d", tt.in, id, selected)
+			}
+			continue
+		}
+		if err != nil || id != tt.id || selected != tt.selected {
+			t.Errorf("ParseItemRef(%q) = %d, %d, %v, want %d, %d", tt.in, id, selected, err, tt.id, tt.selected)
+		}
+	}
+}
+
+func TestFindItemRef(t *testing.T) {
+	tests := []struct {
+		in           string
+		id, selected int
+		err          bool
+	}{
+		{in: "8863\n", id: 8863},
+		{in: "Comments: https://news.ycombinator.com/item?id=8863.", id: 8863},
+		{in: "(https://news.ycombinator.com/item?id=8863#9224)", id: 8863, selected: 9224},
+		{in: `<a href="https://example.com">x</a> <a href="https://news.ycombinator.com/item?id=8863">y</a>`, id: 8863},
+		{in: "first https://news.ycombinator.com/item?id=1 then https://news.ycombinator.com/item?id=2", id: 1},
+		{in: "Issue 8863 is fixed", err: true},
+		{in: "see https://example.com/item?id=8863", err: true},
+	}
+	for _, tt := range tests {
+		id, selected, err := FindItemRef(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("FindItemRef(%q): expected an error, got %d, %d", tt.in, id, selected)
+			}
+			continue
+		}
+		if err != nil || id != tt.id || selected != tt.selected {
+			t.Errorf("FindItemRef(%q) = %d, %d, %v, want %d, %d", tt.in, id, selected, err, tt.id, tt.selected)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+package hn
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+// Renderer turns text blocks into styled, wrapped and indented lines. A renderer is immutable once created, so it may
+// be shared between nodes and used concurrently.
+type Renderer struct {
+	plain      bool
+	hyperlinks bool
+	syntax     bool
+	width      WidthPolicy
+	maxIndent  int
+	// Styles indexed by block type, for unselected and selected nodes respectively.
+	styles    [numBlockTypes]lipgloss.Style
+	selected  [numBlockTypes]lipgloss.Style
+	highlight [2]lipgloss.Style
+	selection lipgloss.Style
+	subthread lipgloss.Style
+	// Indentation guides.
+	rails        bool
+	depthColors  bool
+	rail         lipgloss.Style
+	railSelected lipgloss.Style
+	railColors   []lipgloss.Style
+}
+
+// DefaultRenderer is used by nodes which have not been assigned a renderer, see `Story.SetRenderer`.
+var DefaultRenderer = NewRenderer(Themes[DefaultTheme], false)
+
+// NewRenderer returns a renderer using the styles of the given theme. Malformed styles are ignored, see
+// `Theme.Validate`.
+//
+// If plain is set, no escape sequences are emitted and markup is conveyed through textual cues instead, e.g
+// `_italic_`, `> quote`, numbered links, `*` marking the selected node and `|` guides marking indentation.
+func NewRenderer(t Theme, plain bool, opts ...RendererOption) *Renderer {
+	r := &Renderer{
+		plain:        plain,
+		syntax:       true,
+		width:        DefaultWidthPolicy,
+		maxIndent:    DefaultMaxIndent,
+		selection:    LipglossStyle(t.Selection),
+		subthread:    LipglossStyle(t.Subthread),
+		rails:        true,
+		rail:         LipglossStyle(t.Rail),
+		railSelected: LipglossStyle(t.RailSelected),
+	}
+	for _, s := range []struct {
+		typ              BlockType
+		normal, selected string
+	}{
+		{BlockTypeText, t.Text, t.TextSelected},
+		{BlockTypeItalic, t.Italic, ""},
+		{BlockTypeQuote, t.Quote, t.QuoteSelected},
+		{BlockTypeLink, t.Link, t.LinkSelected},
+		{BlockTypeRaw, cmp.Or(t.Raw, t.Text), cmp.Or(t.Raw, t.TextSelected)},
+		{BlockTypeAuthor, t.Author, t.AuthorSelected},
+		{BlockTypeMetadata, t.Metadata, t.MetadataSelected},
+		{BlockTypeTitle, t.Title, ""},
+		{BlockTypeNew, t.New, ""},
+	} {
+		r.styles[s.typ] = LipglossStyle(s.normal)
+		r.selected[s.typ] = LipglossStyle(cmp.Or(s.selected, s.normal)).Inherit(r.selection)
+	}
+	// Code tokens are drawn on top of the code block's style.
+	for typ, spec := range map[BlockType]string{
+		BlockTypeKeyword: t.CodeKeyword,
+		BlockTypeString:  t.CodeString,
+		BlockTypeComment: t.CodeComment,
+		BlockTypeNumber:  t.CodeNumber,
+	} {
+		r.styles[typ] = LipglossStyle(spec).Inherit(r.styles[BlockTypeRaw])
+		r.selected[typ] = LipglossStyle(spec).Inherit(r.selected[BlockTypeRaw])
+	}
+	for _, c := range strings.Fields(t.RailColors) {
+		r.railColors = append(r.railColors, LipglossStyle(c))
+	}
+	r.highlight[0] = LipglossStyle(t.Highlight)
+	r.highlight[1] = r.highlight[0].Inherit(r.selection)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+type RendererOption func(*Renderer)
+
+// WithHyperlinks makes links clickable in terminals supporting OSC 8 hyperlinks. It has no effect in plain mode.
+func WithHyperlinks(b bool) RendererOption {
+	return func(r *Renderer) {
+		r.hyperlinks = b
+	}
+}
+
+// WithRails toggles drawing guides along the indentation of replies. It is enabled by default and has no effect in plain
+// mode, which always marks indentation with guides.
+func WithRails(b bool) RendererOption {
+	return func(r *Renderer) {
+		r.rails = b
+	}
+}
+
+// WithDepthColors colours the indentation guides by depth, using the theme's rail colors.
+func WithDepthColors(b bool) RendererOption {
+	return func(r *Renderer) {
+		r.depthColors = b
+	}
+}
+
+// WithSyntaxHighlighting toggles highlighting of code blocks, whose language is guessed from their content. It is
+// enabled by default and has no effect in plain mode.
+func WithSyntaxHighlighting(b bool) RendererOption {
+	return func(r *Renderer) {
+		r.syntax = b
+	}
+}
+
+// hyperlink wraps the already styled text s in an OSC 8 hyperlink to href. Targets containing control characters could
+// terminate the sequence early and inject escape sequences, so they are not linked.
+func hyperlink(s, href string) string {
+	if strings.ContainsFunc(href, unicode.IsControl) {
+		return s
+	}
+	return "\x1b]8;;" + href + "\x1b\\" + s + "\x1b]8;;\x1b\\"
+}
+
+// span is a run of text sharing a single style and link target. The style is nil in plain mode.
+type span struct {
+	text  string
+	style *lipgloss.Style
+	href  string
+}
+
+// Render renders the header blocks followed by the document (which may be nil) according to the node's state,
+// wrapping text at `state.TextWidth` and indenting it by `state.Depth`, up to the maximum indentation. Lines are padded
+// to `state.Width`, such that the selection's background spans the entire viewport.
+func (r *Renderer) Render(header TextBlocks, doc *Node, state State) string {
+	c := &renderContext{Renderer: r, query: state.Highlight}
+	if !r.plain {
+		c.styles, c.highlight = &r.styles, &r.highlight[0]
+		if state.Selected {
+			c.styles, c.highlight = &r.selected, &r.highlight[1]
+		}
+	}
+
+	var spans []span
+	if indent := r.indentDepth(state.Depth); indent < state.Depth {
+		spans = append(spans, c.text(BlockTypeMetadata, fmt.Sprintf("[depth %d] ", state.Depth))...)
+	}
+	for _, part := range header {
+		c.href = part.Href
+		spans = append(spans, c.text(part.Type, part.Text)...)
+	}
+	c.href = ""
+	lines := wrapSpans(spans, state.TextWidth)
+	if doc != nil {
+		lines = append(lines, c.blocks(doc, state.TextWidth)...)
+	}
+
+	if r.plain {
+		return r.joinPlain(lines, state)
+	}
+
+	indentStyle := lipgloss.NewStyle()
+	padStyle := lipgloss.NewStyle()
+	if state.Selected {
+		indentStyle, padStyle = r.selection, r.selection
+	} else if state.Subthread {
+		indentStyle = r.subthread
+	}
+	indent := [2]string{r.indent(state, indentStyle, true), r.indent(state, indentStyle, false)}
+
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		var (
+			b     strings.Builder
+			width = r.indentWidth(state.Depth)
+		)
+		b.WriteString(indent[min(i, 1)])
+		for _, s := range line {
+			// Hyperlinks are only added once the text has been wrapped, so that they never affect its width.
+			if text := s.style.Render(s.text); r.hyperlinks && s.href != "" {
+				b.WriteString(hyperlink(text, s.href))
+			} else {
+				b.WriteString(text)
+			}
+			width += runewidth.StringWidth(s.text)
+		}
+		if pad := state.Width - width; pad > 0 {
+			b.WriteString(padStyle.Render(strings.Repeat(" ", pad)))
+		}
+		res[i] = b.String()
+	}
+	return strings.Join(res, "\n")
+}
+
+// indent renders the indentation of a line. With rails enabled, each level is marked by a guide, with the first line
+// of a node branching off its parent's guide.
+func (r *Renderer) indent(state State, base lipgloss.Style, first bool) string {
+	n := r.indentDepth(state.Depth)
+	if !r.rails {
+		return base.Render(strings.Repeat(" ", n*2))
+	}
+	var b strings.Builder
+	for i := range n {
+		rail := "│ "
+		if first && i == n-1 {
+			rail = "├ "
+		}
+		style := r.rail
+		if r.depthColors && len(r.railColors) > 0 {
+			style = r.railColors[i%len(r.railColors)]
+		}
+		// Guide i belongs to the ancestor at depth i, so this highlights the guide of the selected node's replies.
+		if state.Subthread && i == state.SubthreadDepth {
+			style = r.railSelected
+		}
+		b.WriteString(style.Inherit(base).Render(rail))
+	}
+	return b.String()
+}
+
+// joinPlain prefixes the lines with textual cues for the selection and indentation.
+func (r *Renderer) joinPlain(lines [][]span, state State) string {
+	gutter := "  "
+	if state.Selected {
+		gutter = "* "
+	}
+	prefix := gutter + strings.Repeat("| ", r.indentDepth(state.Depth))
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		var b strings.Builder
+		b.WriteString(prefix)
+		for _, s := range line {
+			b.WriteString(s.text)
+		}
+		res[i] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(res, "\n")
+}
+
+// renderContext holds the state of rendering a single node.
+type renderContext struct {
+	*Renderer
+	styles    *[numBlockTypes]lipgloss.Style
+	highlight *lipgloss.Style
+	query     string
+	// href is the target of the link being rendered, if any.
+	href string
+	// links is the number of links rendered so far, used for numbering them in plain mode.
+	links int
+}
+
+func (c *renderContext) style(typ BlockType) *lipgloss.Style {
+	if c.plain {
+		return nil
+	}
+	return &c.styles[typ]
+}
+
+// text returns the spans for text of the given type, highlighting matches of the search query.
+func (c *renderContext) text(typ BlockType, s string) []span {
+	style := c.style(typ)
+	// Metadata is not searchable, so its matches are not highlighted either.
+	if c.plain || typ == BlockTypeMetadata || typ == BlockTypeNew {
+		return []span{{s, style, c.href}}
+	}
+	var (
+		spans []span
+		i     int
+	)
+	for _, match := range threadview.FindMatches(s, c.query) {
+		spans = append(spans,
+			span{s[i:match[0]], style, c.href},
+			span{s[match[0]:match[1]], c.highlight, c.href},
+		)
+		i = match[1]
+	}
+	return append(spans, span{s[i:], style, c.href})
+}
+
+// blocks renders the blocks of a document into lines of at most width cells.
+func (c *renderContext) blocks(doc *Node, width int) [][]span {
+	var lines [][]span
+	for _, b := range doc.Children {
+		switch b.Kind {
+		case NodeParagraph:
+			if spans := c.inline(b, BlockTypeText, nil); len(spans) > 0 {
+				lines = append(lines, wrapSpans(spans, width)...)
+			}
+		case NodeQuote:
+			prefix := span{"> ", c.style(BlockTypeQuote), ""}
+			for _, p := range b.Children {
+				for _, line := range wrapSpans(c.inline(p, BlockTypeQuote, nil), width-2) {
+					lines = append(lines, append([]span{prefix}, line...))
+				}
+			}
+		case NodeCodeBlock:
+			lines = append(lines, c.code(b.Text, width)...)
+		}
+	}
+	return lines
+}
+
+// code renders a code block verbatim. Lines are truncated rather than wrapped, and padded to a common width such that
+// the block's background forms a rectangle.
+func (c *renderContext) code(text string, width int) [][]span {
+	text = expandTabs(strings.TrimRight(text, "\n"), 4)
+	var lang *language
+	if c.syntax && !c.plain {
+		lang = guessLanguage(text)
+	}
+	blockW := 0
+	for _, l := range strings.Split(text, "\n") {
+		blockW = max(blockW, runewidth.StringWidth(l))
+	}
+	blockW = min(blockW, max(width, 1))
+	marker := "…"
+	if c.plain {
+		marker = "$"
+	}
+
+	tokens := highlightCode(text, lang)
+	lines := make([][]span, len(tokens))
+	for i, line := range tokens {
+		var spans []span
+		for _, tok := range line {
+			spans = append(spans, c.text(tok.Type, tok.Text)...)
+		}
+		spans, w := truncateSpans(spans, blockW, marker)
+		if pad := blockW - w; pad > 0 {
+			spans = append(spans, span{strings.Repeat(" ", pad), c.style(BlockTypeRaw), ""})
+		}
+		lines[i] = spans
+	}
+	return lines
+}
+
+// truncateSpans cuts the spans to at most width cells, ending them with marker if anything was cut. It returns the
+// resulting spans along with their width.
+func truncateSpans(spans []span, width int, marker string) ([]span, int) {
+	total := 0
+	for _, s := range spans {
+		total += runewidth.StringWidth(s.text)
+	}
+	if total <= width {
+		return spans, total
+	}
+	var (
+		res   []span
+		w     int
+		limit = width - runewidth.StringWidth(marker)
+	)
+	for _, s := range spans {
+		var b strings.Builder
+		for _, r := range s.text {
+			rw := runewidth.RuneWidth(r)
+			if w+rw > limit {
+				break
+			}
+			b.WriteRune(r)
+			w += rw
+		}
+		if b.Len() > 0 {
+			res = append(res, span{b.String(), s.style, s.href})
+		}
+		if b.Len() < len(s.text) {
+			res = append(res, span{marker, s.style, s.href})
+			return res, w + runewidth.StringWidth(marker)
+		}
+	}
+	return res, w
+}
+
+// inline appends the spans of the inline node n, rendered as typ unless the node overrides it. Links take precedence
+// over quotes, which in turn take precedence over emphasis.
+func (c *renderContext) inline(n *Node, typ BlockType, spans []span) []span {
+	var suffix string
+	switch n.Kind {
+	case NodeText:
+		return append(spans, c.text(typ, n.Text)...)
+	case NodeLineBreak:
+		return append(spans, span{"\n", c.style(typ), ""})
+	case NodeEmphasis:
+		if typ == BlockTypeText {
+			typ = BlockTypeItalic
+		}
+		if c.plain {
+			spans = append(spans, span{"_", nil, ""})
+			suffix = "_"
+		}
+	case NodeLink:
+		typ = BlockTypeLink
+		defer func(href string) { c.href = href }(c.href)
+		c.href = n.Href
+		if c.plain {
+			c.links++
+			suffix = fmt.Sprintf(" [%d]", c.links)
+		}
+	}
+	for _, child := range n.Children {
+		spans = c.inline(child, typ, spans)
+	}
+	if suffix != "" {
+		spans = append(spans, span{suffix, nil, ""})
+	}
+	return spans
+}
+
+// wrapSpans breaks the spans into lines of at most width cells. Lines are broken at spaces, which are dropped at the
+// break, while words exceeding the width on their own are broken wherever necessary. Explicit newlines are retained.
+func wrapSpans(spans []span, width int) [][]span {
+	var (
+		lines                [][]span
+		line, space, word    []span
+		lineW, spaceW, wordW int
+	)
+	width = max(1, width)
+
+	// add appends s to the run, merging it with the run's last span if their styles and targets match.
+	add := func(run []span, s span) []span {
+		if n := len(run); n > 0 && run[n-1].style == s.style && run[n-1].href == s.href {
+			run[n-1].text += s.text
+			return run
+		}
+		return append(run, s)
+	}
+	concat := func(run []span, spans ...span) []span {
+		for _, s := range spans {
+			run = add(run, s)
+		}
+		return run
+	}
+	breakLine := func() {
+		lines = append(lines, line)
+		line, space = nil, nil
+		lineW, spaceW = 0, 0
+	}
+	flushWord := func() {
+		if wordW == 0 {
+			return
+		}
+		if lineW > 0 && lineW+spaceW+wordW > width {
+			breakLine()
+		}
+		line = concat(line, space...)
+		line = concat(line, word...)
+		lineW += spaceW + wordW
+		space, word = nil, nil
+		spaceW, wordW = 0, 0
+	}
+
+	for _, s := range spans {
+		for _, c := range s.text {
+			switch c {
+			case '\n':
+				flushWord()
+				breakLine()
+			case ' ':
+				flushWord()
+				space = add(space, span{" ", s.style, s.href})
+				spaceW++
+			default:
+				w := runewidth.RuneWidth(c)
+				if wordW+w > width {
+					// The word does not fit on a line of its own, so break it.
+					if lineW > 0 {
+						breakLine()
+					}
+					line = concat(space, word...)
+					lineW = spaceW + wordW
+					space, word = nil, nil
+					spaceW, wordW = 0, 0
+					breakLine()
+				}
+				word = add(word, span{string(c), s.style, s.href})
+				wordW += w
+			}
+		}
+	}
+	flushWord()
+	lines = append(lines, line)
+	return lines
+}
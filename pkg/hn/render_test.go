@@ -0,0 +1,100 @@
+package hn
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/toalaah/hn/pkg/threadview"
+)
+
+const renderMarkup = `Some text with <i>emphasis</i> and a <a href="https://example.com">link</a> that is long enough to wrap.` +
+	`<p>&gt; a quote<pre><code>x := 1</code></pre>`
+
+var renderHeader = TextBlocks{
+	{Type: BlockTypeAuthor, Text: "pg"},
+	{Type: BlockTypeText, Text: " "},
+	{Type: BlockTypeMetadata, Text: "(1 hour ago)"},
+}
+
+func renderState(selected bool, depth, width int) State {
+	return State{
+		DisplayStateMsg: threadview.DisplayStateMsg{Selected: selected, Depth: depth, Width: width},
+		TextWidth:       30,
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	r := NewRenderer(Themes[DefaultTheme], true)
+	doc, _ := parseMarkup(renderMarkup)
+	tests := []struct {
+		selected bool
+		want     string
+	}{
+		{false, `  | pg (1 hour ago)
+  | Some text with _emphasis_ and
+  | a link [1] that is long enough
+  | to wrap.
+  | > a quote
+  | x := 1`},
+		{true, `* | pg (1 hour ago)
+* | Some text with _emphasis_ and
+* | a link [1] that is long enough
+* | to wrap.
+* | > a quote
+* | x := 1`},
+	}
+	for _, tt := range tests {
+		if got := r.Render(renderHeader, doc, renderState(tt.selected, 1, 60)); got != tt.want {
+			t.Errorf("selected %v: got\n%s\nwant\n%s", tt.selected, got, tt.want)
+		}
+	}
+}
+
+func TestRenderStyled(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	doc, _ := parseMarkup(renderMarkup)
+	for _, hyperlinks := range []bool{false, true} {
+		r := NewRenderer(Themes[DefaultTheme], false, WithHyperlinks(hyperlinks))
+		for _, selected := range []bool{false, true} {
+			s := r.Render(renderHeader, doc, renderState(selected, 2, 60))
+			if !strings.Contains(s, "\x1b[") {
+				t.Errorf("no escape sequences in %q", s)
+			}
+			if got := strings.Contains(s, "\x1b]8;;https://example.com"); got != hyperlinks {
+				t.Errorf("hyperlinks %v: got hyperlink %v", hyperlinks, got)
+			}
+			// Lines are padded to the width of the window, regardless of their styles.
+			for _, line := range strings.Split(s, "\n") {
+				if w := lipgloss.Width(line); w != 60 {
+					t.Errorf("line %q is %d cells wide, want 60", line, w)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderConcurrent(t *testing.T) {
+	r := NewRenderer(Themes[DefaultTheme], false)
+	doc, _ := parseMarkup(renderMarkup)
+	want := r.Render(renderHeader, doc, renderState(true, 1, 80))
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Rendering other states in between must not affect the result.
+			r.Render(renderHeader, doc, renderState(i%2 == 0, i, 40+i))
+			if got := r.Render(renderHeader, doc, renderState(true, 1, 80)); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
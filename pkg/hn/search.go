@@ -0,0 +1,116 @@
+package hn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchOptions narrows down the results of a search.
+type SearchOptions struct {
+	// Tags restricts results to items of a given type, e.g "story" or "comment".
+	Tags string
+	// Author restricts results to items submitted by the given user.
+	Author string
+	// Since restricts results to items created after the given time.
+	Since time.Time
+	// MinPoints restricts results to items with at least the given number of points.
+	MinPoints int
+	// ByDate sorts results by date, most recent first, instead of by relevance.
+	ByDate bool
+	// HitsPerPage is the maximum number of results returned.
+	HitsPerPage int
+}
+
+type searchHit struct {
+	ObjectID    string    `json:"objectID"`
+	Author      string    `json:"author"`
+	Date        time.Time `json:"created_at"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Points      int       `json:"points"`
+	StoryText   string    `json:"story_text"`
+	CommentText string    `json:"comment_text"`
+	NumComments int       `json:"num_comments"`
+	StoryID     int       `json:"story_id"`
+	StoryTitle  string    `json:"story_title"`
+	ParentID    int       `json:"parent_id"`
+}
+
+// Search queries Algolia's full-text index. The returned items carry no children; stories and comments may be opened
+// through `Thread` using their `Id` and `StoryID` respectively.
+func (s *AlgoliaSource) Search(ctx context.Context, query string, opts SearchOptions) ([]*Story, error) {
+	params := url.Values{}
+	params.Set("query", query)
+
+	var tags []string
+	if opts.Tags != "" {
+		tags = append(tags, opts.Tags)
+	}
+	if opts.Author != "" {
+		tags = append(tags, "author_"+opts.Author)
+	}
+	if len(tags) > 0 {
+		params.Set("tags", strings.Join(tags, ","))
+	}
+
+	var filters []string
+	if !opts.Since.IsZero() {
+		filters = append(filters, fmt.Sprintf("created_at_i>%d", opts.Since.Unix()))
+	}
+	if opts.MinPoints > 0 {
+		filters = append(filters, fmt.Sprintf("points>=%d", opts.MinPoints))
+	}
+	if len(filters) > 0 {
+		params.Set("numericFilters", strings.Join(filters, ","))
+	}
+	if opts.HitsPerPage > 0 {
+		params.Set("hitsPerPage", strconv.Itoa(opts.HitsPerPage))
+	}
+
+	endpoint := "/search"
+	if opts.ByDate {
+		endpoint = "/search_by_date"
+	}
+	body, err := s.get(ctx, endpoint+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Hits []searchHit `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Story, 0, len(resp.Hits))
+	for _, h := range resp.Hits {
+		id, err := strconv.Atoi(h.ObjectID)
+		if err != nil {
+			continue
+		}
+		t := &Story{
+			Id:          id,
+			Author:      h.Author,
+			Date:        h.Date,
+			TextRaw:     h.StoryText + h.CommentText,
+			Title:       h.Title,
+			Points:      h.Points,
+			Descendants: h.NumComments,
+			StoryID:     h.StoryID,
+			StoryTitle:  h.StoryTitle,
+			ParentID:    h.ParentID,
+		}
+		if u, err := url.Parse(h.URL); err == nil && u.Scheme != "" {
+			t.URL = u
+		}
+		initNodes(t)
+		res = append(res, t)
+	}
+	return res, nil
+}
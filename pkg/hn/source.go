@@ -0,0 +1,95 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Source is a backend capable of fetching a complete story tree.
+type Source interface {
+	// Thread fetches the item with the given id along with all of its descendants.
+	Thread(ctx context.Context, id int) (*Story, error)
+}
+
+// RawSource is implemented by sources which can provide a story as received from their backend, such that it can be
+// cached verbatim and decoded later on.
+type RawSource interface {
+	Source
+	// Name identifies the source. Data of different sources is not interchangeable.
+	Name() string
+	// Fetch fetches the item with the given id along with all of its descendants, in a format understood by `Decode`.
+	Fetch(ctx context.Context, id int) ([]byte, error)
+	// Decode parses data returned by `Fetch`.
+	Decode(data []byte) (*Story, error)
+}
+
+// List identifies one of the story rankings maintained by HN.
+type List string
+
+const (
+	ListTop  List = "top"
+	ListNew  List = "new"
+	ListBest List = "best"
+	ListAsk  List = "ask"
+	ListShow List = "show"
+	ListJobs List = "job"
+)
+
+// Lister is implemented by sources which can enumerate the stories of a ranking such as the front page.
+type Lister interface {
+	// Stories fetches the first n stories of the given list, without their comments.
+	Stories(ctx context.Context, list List, n int) ([]*Story, error)
+}
+
+// Client holds the transport settings shared by all HTTP-based sources.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+}
+
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used to issue requests. By default, `http.DefaultClient` is used.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(cl *Client) {
+		cl.HTTPClient = c
+	}
+}
+
+// WithBaseURL overrides the backend's default API root, e.g to point at a local mirror or a test server.
+func WithBaseURL(u string) ClientOption {
+	return func(cl *Client) {
+		cl.BaseURL = strings.TrimRight(u, "/")
+	}
+}
+
+func newClient(baseURL string, opts ...ClientOption) Client {
+	c := Client{
+		HTTPClient: http.DefaultClient,
+		BaseURL:    baseURL,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// get issues a GET request against the path relative to the client's base URL and returns the response body.
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", req.URL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
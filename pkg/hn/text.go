@@ -1,13 +1,11 @@
 package hn
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 
-	"github.com/fatih/color"
-	"github.com/muesli/reflow/indent"
-	"github.com/muesli/reflow/padding"
-	"github.com/muesli/reflow/wordwrap"
 	"golang.org/x/net/html"
 )
 
@@ -22,137 +20,286 @@ const (
 	// Define some non-comment-specific blocks so that we can re-use the render function below for the header.
 	BlockTypeAuthor
 	BlockTypeMetadata
+	BlockTypeTitle
+	BlockTypeNew
+	// Tokens of highlighted code blocks, see `highlightCode`.
+	BlockTypeKeyword
+	BlockTypeString
+	BlockTypeComment
+	BlockTypeNumber
+	numBlockTypes
 )
 
 type TextBlock struct {
 	Type BlockType
 	Text string
+	// Href is the target of link blocks, if known.
+	Href string
 }
 
 type TextBlocks []TextBlock
 
-type TextStyle struct {
-	Unselected *color.Color
-	Selected   *color.Color
-}
-
-func (t TextStyle) Get(selected bool) *color.Color {
-	if selected && t.Selected != nil {
-		return t.Selected
-	}
-	return t.Unselected
-}
-
-var textStyles = struct {
-	Text,
-	Link,
-	Quote,
-	Author,
-	Metadata TextStyle
-	SelectedBg *color.Color
-}{
-	Text:       TextStyle{color.Set(color.FgWhite), nil},
-	Link:       TextStyle{color.Set(color.FgRed), nil},
-	Quote:      TextStyle{color.Set(color.Faint), color.Set(color.FgYellow)},
-	Author:     TextStyle{color.Set(color.FgHiYellow, color.Bold), nil},
-	Metadata:   TextStyle{color.Set(color.Faint), color.Set(color.FgWhite)},
-	SelectedBg: color.Set(color.BgBlue),
-}
-
-func (t TextBlocks) Render(state State) string {
-	var (
-		res string
-
-		normal = textStyles.Text.Get(state.Selected)
-		link   = textStyles.Link.Get(state.Selected)
-		quote  = textStyles.Quote.Get(state.Selected)
-		author = textStyles.Author.Get(state.Selected)
-		meta   = textStyles.Metadata.Get(state.Selected)
-	)
-
-	write := func(w io.Writer, c *color.Color, text string) {
-		normal.SetWriter(w)
-		// c.SetWriter(w)
-		c.Fprint(w, text)
-		if state.Selected {
-			textStyles.SelectedBg.SetWriter(w)
-		}
-		normal.SetWriter(w)
-	}
-
-	// Render comment w/ ansi markup
-	{
-		b := &strings.Builder{}
-		for _, part := range t {
-			switch part.Type {
-			case BlockTypeText:
-				fallthrough
-			default:
-				write(b, normal, part.Text)
-			case BlockTypeLink:
-				write(b, link, part.Text)
-			case BlockTypeItalic:
-				write(b, color.Set(color.Italic), part.Text)
-			case BlockTypeQuote:
-				write(b, quote, part.Text)
-			case BlockTypeAuthor:
-				write(b, author, part.Text)
-			case BlockTypeMetadata:
-				write(b, meta, part.Text)
-			}
-		}
+// NodeKind identifies the kind of a node in a comment's document tree.
+type NodeKind int
 
-		res = wordwrap.String(b.String(), state.TextWidth)
-		i := indent.NewWriter(1, func(w io.Writer) {
-			if state.Selected {
-				textStyles.SelectedBg.SetWriter(w)
+const (
+	// Block-level nodes. A document consists of paragraphs, quotes and code blocks, while quotes consist of
+	// paragraphs.
+	NodeDocument NodeKind = iota
+	NodeParagraph
+	NodeQuote
+	NodeCodeBlock
+	// Inline nodes, which make up paragraphs.
+	NodeText
+	NodeEmphasis
+	NodeLink
+	NodeLineBreak
+)
+
+// Node is a node in the document tree of a comment's markup. Text is set for text nodes and code blocks, whose
+// content is kept verbatim, and Href is set for links.
+type Node struct {
+	Kind     NodeKind
+	Text     string
+	Href     string
+	Children []*Node
+}
+
+// PlainText returns the text content of the node, with blocks separated by newlines and quoted paragraphs prefixed by
+// "> ".
+func (n *Node) PlainText() string {
+	var b strings.Builder
+	n.writePlainText(&b)
+	return b.String()
+}
+
+func (n *Node) writePlainText(b *strings.Builder) {
+	switch n.Kind {
+	case NodeDocument, NodeQuote:
+		for i, c := range n.Children {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if n.Kind == NodeQuote {
+				b.WriteString("> ")
 			}
-			w.Write([]byte(strings.Repeat(" ", state.Depth*2)))
-		})
-		_, _ = i.Write([]byte(res))
-		res = i.String()
-		res = padding.String(res, uint(state.Width))
+			c.writePlainText(b)
+		}
+	case NodeCodeBlock:
+		b.WriteString(strings.TrimRight(n.Text, "\n"))
+	case NodeText:
+		b.WriteString(n.Text)
+	case NodeLineBreak:
+		b.WriteString("\n")
+	default:
+		for _, c := range n.Children {
+			c.writePlainText(b)
+		}
 	}
+}
 
+// Links returns the links contained in the node in document order.
+func (n *Node) Links() []*Node {
+	var res []*Node
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Kind == NodeLink {
+			res = append(res, n)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(n)
 	return res
 }
 
-func parseMarkupToBlocks(s string) []TextBlock {
-	var (
-		parts     = make([]TextBlock, 0)
-		state     = BlockTypeText
-		tokenizer = html.NewTokenizer(strings.NewReader(s))
-	)
-	for tokenType := tokenizer.Next(); tokenType != html.ErrorToken; tokenType = tokenizer.Next() {
+// inlineTags are the formatting tags which HN emits (or has emitted in the past) that do not start a new block,
+// mapped to the node they produce. Tags mapping to text nodes are transparent, i.e their content is kept as is.
+var inlineTags = map[string]NodeKind{
+	"i":      NodeEmphasis,
+	"em":     NodeEmphasis,
+	"a":      NodeLink,
+	"code":   NodeText,
+	"b":      NodeText,
+	"strong": NodeText,
+	"u":      NodeText,
+	"s":      NodeText,
+	"span":   NodeText,
+}
+
+// element is an open inline element. Its node is nil for transparent and unsupported tags.
+type element struct {
+	tag  string
+	node *Node
+}
+
+type markupParser struct {
+	doc   *Node
+	para  *Node // the paragraph being built, if any
+	fresh bool  // whether the paragraph has no text yet
+	code  *Node // the code block being built, if any
+	open  []element
+	diags []error
+}
+
+// parseMarkup converts HN comment markup into a document tree. Malformed or unsupported markup never aborts the parse;
+// the affected content is kept as plain text and a diagnostic is returned for each problem encountered.
+//
+// Paragraphs starting with '>' are quotes, with consecutive quoted paragraphs being merged into a single quote.
+func parseMarkup(s string) (*Node, []error) {
+	p := &markupParser{doc: &Node{Kind: NodeDocument}}
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				p.diags = append(p.diags, err)
+			}
+			return p.doc, p.diags
+		}
 		token := tokenizer.Token()
 		switch tokenType {
-		case html.StartTagToken:
-			switch token.Data {
-			case "p":
-				state = BlockTypeText
-				parts = append(parts, TextBlock{BlockTypeText, "\n"})
-			case "i":
-				if state == BlockTypeText {
-					state = BlockTypeItalic
-				}
-			case "a":
-				state = BlockTypeLink
-			case "pre":
-				state = BlockTypeRaw
-				parts = append(parts, TextBlock{BlockTypeText, "\n"})
-			case "code":
-				break
-			default:
-				panic("unhandled start tag: " + token.Data)
-			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			p.startTag(token, tokenType == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			p.endTag(token)
 		case html.TextToken:
-			if strings.HasPrefix(token.Data, ">") {
-				state = BlockTypeQuote
+			p.text(token.Data)
+		}
+	}
+}
+
+func (p *markupParser) startTag(token html.Token, selfClosing bool) {
+	if p.code != nil {
+		// Markup inside code blocks is limited to the <code> element itself.
+		if token.Data != "code" {
+			p.diags = append(p.diags, fmt.Errorf("unsupported tag <%s> in code block", token.Data))
+		}
+		return
+	}
+	switch token.Data {
+	case "p":
+		p.endParagraph()
+	case "br":
+		p.appendInline(&Node{Kind: NodeLineBreak})
+	case "pre":
+		p.endParagraph()
+		if !selfClosing {
+			p.code = &Node{Kind: NodeCodeBlock}
+			p.doc.Children = append(p.doc.Children, p.code)
+		}
+	default:
+		kind, ok := inlineTags[token.Data]
+		if !ok {
+			p.diags = append(p.diags, fmt.Errorf("unsupported tag <%s>", token.Data))
+		}
+		if selfClosing {
+			return
+		}
+		e := element{tag: token.Data}
+		if kind != NodeText && ok {
+			e.node = &Node{Kind: kind}
+			for _, a := range token.Attr {
+				if kind == NodeLink && a.Key == "href" {
+					e.node.Href = a.Val
+				}
 			}
-			parts = append(parts, TextBlock{state, token.Data})
-		case html.EndTagToken:
-			state = BlockTypeText
+			p.appendInline(e.node)
+		}
+		p.open = append(p.open, e)
+	}
+}
+
+func (p *markupParser) endTag(token html.Token) {
+	switch token.Data {
+	case "p", "br":
+		return
+	case "pre":
+		if p.code == nil {
+			p.diags = append(p.diags, errors.New("unexpected </pre>"))
 		}
+		p.code = nil
+		return
 	}
-	return parts
+	if p.code != nil {
+		return
+	}
+	// Close the innermost matching element along with anything left unclosed inside it.
+	i := len(p.open) - 1
+	for i >= 0 && p.open[i].tag != token.Data {
+		i--
+	}
+	if i < 0 {
+		p.diags = append(p.diags, fmt.Errorf("unexpected </%s>", token.Data))
+		return
+	}
+	p.open = p.open[:i]
+}
+
+func (p *markupParser) text(s string) {
+	if p.code != nil {
+		p.code.Text += s
+		return
+	}
+	if p.para == nil && strings.TrimSpace(s) == "" {
+		return
+	}
+	p.ensureParagraph()
+	if p.fresh {
+		p.fresh = false
+		if trimmed := strings.TrimLeft(s, " "); strings.HasPrefix(trimmed, ">") {
+			p.quoteParagraph()
+			s = strings.TrimLeft(trimmed[1:], " ")
+		}
+	}
+	if s != "" {
+		p.appendInline(&Node{Kind: NodeText, Text: s})
+	}
+}
+
+// appendInline appends n to the innermost open element, starting a new paragraph if necessary.
+func (p *markupParser) appendInline(n *Node) {
+	p.ensureParagraph()
+	parent := p.para
+	for i := len(p.open) - 1; i >= 0; i-- {
+		if p.open[i].node != nil {
+			parent = p.open[i].node
+			break
+		}
+	}
+	parent.Children = append(parent.Children, n)
+}
+
+func (p *markupParser) ensureParagraph() {
+	if p.para != nil {
+		return
+	}
+	p.para = &Node{Kind: NodeParagraph}
+	p.fresh = true
+	p.doc.Children = append(p.doc.Children, p.para)
+	// Elements left open across a paragraph break continue in the new paragraph.
+	parent := p.para
+	for i, e := range p.open {
+		if e.node != nil {
+			n := &Node{Kind: e.node.Kind, Href: e.node.Href}
+			parent.Children = append(parent.Children, n)
+			p.open[i].node, parent = n, n
+		}
+	}
+}
+
+// quoteParagraph moves the current paragraph into a quote, merging it with a directly preceding one.
+func (p *markupParser) quoteParagraph() {
+	blocks := p.doc.Children[:len(p.doc.Children)-1]
+	if n := len(blocks); n > 0 && blocks[n-1].Kind == NodeQuote {
+		blocks[n-1].Children = append(blocks[n-1].Children, p.para)
+	} else {
+		blocks = append(blocks, &Node{Kind: NodeQuote, Children: []*Node{p.para}})
+	}
+	p.doc.Children = blocks
+}
+
+func (p *markupParser) endParagraph() {
+	p.para = nil
 }
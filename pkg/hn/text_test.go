@@ -0,0 +1,95 @@
+package hn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dumpNode renders the document tree of n in a compact form for comparison.
+func dumpNode(n *Node) string {
+	var name string
+	switch n.Kind {
+	case NodeDocument:
+		name = "doc"
+	case NodeParagraph:
+		name = "p"
+	case NodeQuote:
+		name = "quote"
+	case NodeCodeBlock:
+		return fmt.Sprintf("pre(%q)", n.Text)
+	case NodeText:
+		return fmt.Sprintf("%q", n.Text)
+	case NodeEmphasis:
+		name = "em"
+	case NodeLink:
+		name = fmt.Sprintf("a[%s]", n.Href)
+	case NodeLineBreak:
+		return "br"
+	}
+	children := make([]string, len(n.Children))
+	for i, c := range n.Children {
+		children[i] = dumpNode(c)
+	}
+	return name + "(" + strings.Join(children, " ") + ")"
+}
+
+func TestParseMarkup(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		diags int
+	}{
+		{"empty", "", `doc()`, 0},
+		{"text", "Hello", `doc(p("Hello"))`, 0},
+		{"paragraphs", "a<p>b", `doc(p("a") p("b"))`, 0},
+		{"line break", "a<br>b", `doc(p("a" br "b"))`, 0},
+		{"emphasis", "a <i>b</i> c", `doc(p("a " em("b") " c"))`, 0},
+		{"link", `<a href="https://example.com" rel="nofollow">example</a>`, `doc(p(a[https://example.com]("example")))`, 0},
+		{"entities", "a &amp; b &lt;c&gt; &#x27;d&#x27; &quot;e&quot;", `doc(p("a & b <c> 'd' \"e\""))`, 0},
+		{"transparent tags", "<b>a</b> <code>b</code>", `doc(p("a" " " "b"))`, 0},
+		{"unknown tag", "a <blink>b</blink> c", `doc(p("a " "b" " c"))`, 1},
+		{"unknown self-closing tag", "a<img src=x />b", `doc(p("a" "b"))`, 1},
+		{"unclosed tag", "<i>a<p>b", `doc(p(em("a")) p(em("b")))`, 0},
+		{"stray closing tag", "a</i>b", `doc(p("a" "b"))`, 1},
+		{"misnested tags", "<i>a<a href=x>b</i>c</a>", `doc(p(em("a" a[x]("b")) "c"))`, 1},
+		{"quote", "&gt; a", `doc(quote(p("a")))`, 0},
+		{"merged quotes", "&gt; a<p>&gt; b<p>c", `doc(quote(p("a") p("b")) p("c"))`, 0},
+		{"emphasised quote", "<i>&gt; quote</i>", `doc(quote(p(em("quote"))))`, 0},
+		{"code block", "a<pre><code>  x := 1\n  y\n</code></pre>b", `doc(p("a") pre("  x := 1\n  y\n") p("b"))`, 0},
+		{"pre without code", "<pre>  x &lt; y</pre>", `doc(pre("  x < y"))`, 0},
+		{"tag in code block", "<pre><code><b>x</b></code></pre>", `doc(pre("x"))`, 1},
+		{"unclosed code block", "<pre><code>x", `doc(pre("x"))`, 0},
+		{"stray pre", "a</pre>", `doc(p("a"))`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, diags := parseMarkup(tt.in)
+			if got := dumpNode(doc); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if len(diags) != tt.diags {
+				t.Errorf("got diagnostics %v, want %d", diags, tt.diags)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	doc, _ := parseMarkup("a <i>b</i><p>&gt; c<p>&gt; d<pre><code>e\n</code></pre>f<br>g")
+	want := "a b\n> c\n> d\ne\nf\ng"
+	if got := doc.PlainText(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func FuzzParseMarkup(f *testing.F) {
+	for _, s := range []string{"a<p>b", "<i>&gt; a</i>", "<pre><code>x</pre>", "</a></i><p>", "<a href=x><i>b</a>"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		doc, _ := parseMarkup(s)
+		doc.PlainText()
+	})
+}
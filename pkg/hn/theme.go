@@ -0,0 +1,288 @@
+package hn
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme defines the styles used when rendering threads. Each style is a space-separated list of attributes (bold,
+// italic, faint, underline, reverse) and colors. A plain color sets the foreground, a color prefixed with "bg:" the
+// background. Colors are either names (e.g "red", "bright-blue"), ANSI color numbers (0-255) or hex codes ("#rrggbb").
+//
+// Styles suffixed with "Selected" are used in place of their counterparts for the selected comment. Empty selected
+// styles fall back to the unselected style.
+type Theme struct {
+	Text, TextSelected   string
+	Italic               string
+	Link, LinkSelected   string
+	Quote, QuoteSelected string
+	// Code blocks, whose background is applied to the entire block.
+	Raw string
+	// Syntax highlighting of code blocks, on top of the code block's style.
+	CodeKeyword, CodeString    string
+	CodeComment, CodeNumber    string
+	Author, AuthorSelected     string
+	Metadata, MetadataSelected string
+	Title                      string
+	New                        string
+	// Background of the selected comment.
+	Selection string
+	// Indentation of the comments below the selected comment.
+	Subthread string
+	// Indentation guides, with the guide of the selected comment's replies drawn as RailSelected. RailColors is a
+	// space-separated list of colors cycled through by depth, used if depth colouring is enabled.
+	Rail, RailSelected string
+	RailColors         string
+	// Search matches.
+	Highlight string
+	StatusBar string
+}
+
+// Themes contains the built-in themes, keyed by name.
+var Themes = map[string]Theme{
+	"dark": {
+		Text:             "white",
+		Italic:           "italic",
+		Link:             "red",
+		Quote:            "faint",
+		QuoteSelected:    "yellow",
+		Raw:              "white bg:236",
+		CodeKeyword:      "bright-magenta",
+		CodeString:       "green",
+		CodeComment:      "bright-black italic",
+		CodeNumber:       "cyan",
+		Author:           "bright-yellow bold",
+		Metadata:         "faint",
+		MetadataSelected: "white",
+		Title:            "bright-white bold",
+		New:              "bright-green bold",
+		Rail:             "bright-black",
+		RailSelected:     "bright-white bold",
+		RailColors:       "red yellow green cyan blue magenta",
+		Selection:        "bg:blue",
+		Highlight:        "black bg:yellow",
+		StatusBar:        "bg:black",
+	},
+	"light": {
+		Text:             "black",
+		Italic:           "italic",
+		Link:             "red",
+		Quote:            "bright-black",
+		QuoteSelected:    "94",
+		Raw:              "black bg:255",
+		CodeKeyword:      "magenta",
+		CodeString:       "green",
+		CodeComment:      "bright-black italic",
+		CodeNumber:       "blue",
+		Author:           "blue bold",
+		Metadata:         "bright-black",
+		MetadataSelected: "black",
+		Title:            "black bold",
+		New:              "green bold",
+		Rail:             "250",
+		RailSelected:     "black bold",
+		RailColors:       "red 130 green cyan blue magenta",
+		Selection:        "bg:153",
+		Subthread:        "bg:254",
+		Highlight:        "black bg:bright-yellow",
+		StatusBar:        "black bg:252",
+	},
+	"solarized": {
+		Text:             "#839496",
+		TextSelected:     "#93a1a1",
+		Italic:           "italic",
+		Link:             "#268bd2 underline",
+		Quote:            "#586e75",
+		QuoteSelected:    "#b58900",
+		Raw:              "#93a1a1 bg:#073642",
+		CodeKeyword:      "#859900",
+		CodeString:       "#2aa198",
+		CodeComment:      "#586e75 italic",
+		CodeNumber:       "#d33682",
+		Author:           "#b58900 bold",
+		Metadata:         "#586e75",
+		MetadataSelected: "#93a1a1",
+		Title:            "#93a1a1 bold",
+		New:              "#859900 bold",
+		Rail:             "#586e75",
+		RailSelected:     "#93a1a1 bold",
+		RailColors:       "#dc322f #b58900 #859900 #2aa198 #268bd2 #6c71c4",
+		Selection:        "bg:#073642",
+		Subthread:        "bg:#002b36",
+		Highlight:        "#002b36 bg:#b58900",
+		StatusBar:        "#93a1a1 bg:#073642",
+	},
+	"high-contrast": {
+		Text:         "bright-white",
+		Italic:       "italic",
+		Link:         "bright-cyan underline",
+		Quote:        "bright-yellow",
+		Raw:          "bright-white bg:black",
+		CodeKeyword:  "bright-magenta bold",
+		CodeString:   "bright-green",
+		CodeComment:  "bright-cyan",
+		CodeNumber:   "bright-yellow",
+		Author:       "bright-white bold underline",
+		Metadata:     "white",
+		Title:        "bright-white bold",
+		New:          "bright-green bold",
+		Rail:         "white",
+		RailSelected: "bright-yellow bold",
+		RailColors:   "bright-red bright-yellow bright-green bright-cyan bright-blue bright-magenta",
+		Selection:    "bg:blue",
+		Subthread:    "bg:bright-black",
+		Highlight:    "black bg:bright-yellow",
+		StatusBar:    "black bg:white",
+	},
+	"monochrome": {
+		Italic:       "italic",
+		Link:         "underline",
+		Quote:        "faint",
+		CodeKeyword:  "bold",
+		CodeComment:  "faint",
+		Author:       "bold",
+		Metadata:     "faint",
+		Title:        "bold",
+		New:          "bold underline",
+		Rail:         "faint",
+		RailSelected: "bold",
+		Selection:    "reverse",
+		Highlight:    "bold underline",
+		StatusBar:    "reverse",
+	},
+}
+
+// DefaultTheme is the name of the theme used unless configured otherwise.
+const DefaultTheme = "dark"
+
+// Named returns pointers to all styles of the theme, keyed by the name used to refer to them in configuration files.
+func (t *Theme) Named() map[string]*string {
+	return map[string]*string{
+		"text":              &t.Text,
+		"text_selected":     &t.TextSelected,
+		"italic":            &t.Italic,
+		"link":              &t.Link,
+		"link_selected":     &t.LinkSelected,
+		"quote":             &t.Quote,
+		"quote_selected":    &t.QuoteSelected,
+		"raw":               &t.Raw,
+		"code_keyword":      &t.CodeKeyword,
+		"code_string":       &t.CodeString,
+		"code_comment":      &t.CodeComment,
+		"code_number":       &t.CodeNumber,
+		"author":            &t.Author,
+		"author_selected":   &t.AuthorSelected,
+		"metadata":          &t.Metadata,
+		"metadata_selected": &t.MetadataSelected,
+		"title":             &t.Title,
+		"new":               &t.New,
+		"selection":         &t.Selection,
+		"subthread":         &t.Subthread,
+		"rail":              &t.Rail,
+		"rail_selected":     &t.RailSelected,
+		"rail_colors":       &t.RailColors,
+		"highlight":         &t.Highlight,
+		"status_bar":        &t.StatusBar,
+	}
+}
+
+// Validate reports an error if any of the theme's styles is malformed.
+func (t *Theme) Validate() error {
+	named := t.Named()
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if _, err := ParseStyle(*named[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Style is the parsed form of a theme's style.
+type Style struct {
+	Fg, Bg                                  string
+	Bold, Italic, Faint, Underline, Reverse bool
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ParseStyle parses a style as described by `Theme`. Colors are normalized to either ANSI color numbers or hex codes.
+func ParseStyle(spec string) (Style, error) {
+	var s Style
+	for _, tok := range strings.Fields(spec) {
+		switch tok {
+		case "bold":
+			s.Bold = true
+		case "italic":
+			s.Italic = true
+		case "faint":
+			s.Faint = true
+		case "underline":
+			s.Underline = true
+		case "reverse":
+			s.Reverse = true
+		default:
+			bg, isBg := strings.CutPrefix(tok, "bg:")
+			c, err := parseColor(bg)
+			if err != nil {
+				return s, err
+			}
+			if isBg {
+				s.Bg = c
+			} else {
+				s.Fg = c
+			}
+		}
+	}
+	return s, nil
+}
+
+func parseColor(s string) (string, error) {
+	name, bright := strings.CutPrefix(s, "bright-")
+	if i := slices.Index(colorNames, name); i >= 0 {
+		if bright {
+			i += 8
+		}
+		return strconv.Itoa(i), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return s, nil
+	}
+	if len(s) == 7 && s[0] == '#' {
+		if _, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return strings.ToLower(s), nil
+		}
+	}
+	return "", fmt.Errorf("invalid color or attribute: %s", s)
+}
+
+// Lipgloss converts the style to its lipgloss equivalent.
+func (s Style) Lipgloss() lipgloss.Style {
+	st := lipgloss.NewStyle().
+		Bold(s.Bold).
+		Italic(s.Italic).
+		Faint(s.Faint).
+		Underline(s.Underline).
+		Reverse(s.Reverse)
+	if s.Fg != "" {
+		st = st.Foreground(lipgloss.Color(s.Fg))
+	}
+	if s.Bg != "" {
+		st = st.Background(lipgloss.Color(s.Bg))
+	}
+	return st
+}
+
+// LipglossStyle parses the style spec and returns its lipgloss equivalent. Malformed specs yield an empty style.
+func LipglossStyle(spec string) lipgloss.Style {
+	s, _ := ParseStyle(spec)
+	return s.Lipgloss()
+}
@@ -1,12 +1,12 @@
 package hn
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/toalaah/hn/pkg/threadview"
@@ -16,24 +16,70 @@ func (t *Story) ID() int                           { return t.Id }
 func (t *Story) Parent() (threadview.Thread, bool) { return t.parent, t.parent != nil }
 func (t *Story) Children() []threadview.Thread {
 	res := make([]threadview.Thread, len(t.Children_))
-	for i := range t.Children_ {
-		res[i] = &t.Children_[i]
+	for i, c := range t.Children_ {
+		res[i] = c
 	}
 	return res
 }
 
+// SetChildren replaces the replies of t. Replies which are not of type `*Story` are ignored.
+func (t *Story) SetChildren(children []threadview.Thread) {
+	res := make([]*Story, 0, len(children))
+	for _, c := range children {
+		if s, ok := c.(*Story); ok {
+			s.parent = t
+			s.SetRenderer(t.render)
+			res = append(res, s)
+		}
+	}
+	t.Children_ = res
+}
+
 func (t *Story) Text() string {
-	var b strings.Builder
-	for _, p := range t.textParts {
-		b.WriteString(p.Text)
+	if t.doc == nil {
+		return ""
+	}
+	return t.doc.PlainText()
+}
+
+// Links returns the story's URL, if any, followed by the links contained in the text.
+func (t *Story) Links() []threadview.Link {
+	var res []threadview.Link
+	if t.URL != nil {
+		res = append(res, threadview.Link{Text: t.Title, URL: t.URL.String()})
 	}
-	return b.String()
+	if t.doc != nil {
+		for _, l := range t.doc.Links() {
+			res = append(res, threadview.Link{Text: l.PlainText(), URL: cmp.Or(l.Href, l.PlainText())})
+		}
+	}
+	return res
+}
+
+// Unread reports whether the comment was added since the story was last visited.
+func (t *Story) Unread() bool { return t.unread }
+
+// SearchText returns the comment's author and text, separated by a newline.
+func (t *Story) SearchText() string {
+	return t.Author + "\n" + t.Text()
+}
+
+// Diagnostics returns the problems encountered while parsing the markup of the node and its descendants. Affected
+// content is still rendered, falling back to plain text where necessary.
+func (t *Story) Diagnostics() []error {
+	var errs []error
+	dfs(nil, t, func(_, cur *Story) {
+		for _, err := range cur.diagnostics {
+			errs = append(errs, fmt.Errorf("item %d: %w", cur.Id, err))
+		}
+	})
+	return errs
 }
 
 func dfs(root, cur *Story, f func(root, cur *Story)) {
 	f(root, cur)
-	for i := range cur.Children_ {
-		dfs(cur, &cur.Children_[i], f)
+	for _, c := range cur.Children_ {
+		dfs(cur, c, f)
 	}
 }
 
@@ -46,8 +92,8 @@ func commentIndex(cur, target *Story, n int) (int, bool) {
 		return n, true
 	}
 	found := false
-	for i := range cur.Children_ {
-		if n, found = commentIndex(&cur.Children_[i], target, n+1); found {
+	for _, c := range cur.Children_ {
+		if n, found = commentIndex(c, target, n+1); found {
 			return n, found
 		}
 	}
@@ -56,8 +102,10 @@ func commentIndex(cur, target *Story, n int) (int, bool) {
 
 func initNodes(t *Story) {
 	dfs(nil, t, func(root, cur *Story) {
+		// Deleted items may be served as null.
+		cur.Children_ = slices.DeleteFunc(cur.Children_, func(c *Story) bool { return c == nil })
 		cur.parent = root
-		cur.textParts = parseMarkupToBlocks(cur.TextRaw)
+		cur.doc, cur.diagnostics = parseMarkup(cur.TextRaw)
 	})
 }
 
@@ -70,20 +118,12 @@ func NewThreadFromData(body []byte) (*Story, error) {
 	return t, nil
 }
 
+// DefaultSource is the backend used by `NewThread`.
+var DefaultSource Source = NewAlgoliaSource()
+
+// NewThread fetches the story with the given id from `DefaultSource`.
 func NewThread(id int) (*Story, error) {
-	resp, err := http.Get(fmt.Sprintf("https://hn.algolia.com/api/v1/items/%d", id))
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, errors.New(resp.Status)
-	}
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return NewThreadFromData(body)
+	return DefaultSource.Thread(context.Background(), id)
 }
 
 func (t *Story) UnmarshalJSON(data []byte) error {
@@ -106,3 +146,57 @@ func (t *Story) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+func (t *Story) MarshalJSON() ([]byte, error) {
+	type Dummy Story
+
+	tmp := struct {
+		URL string `json:"url,omitempty"`
+		*Dummy
+	}{Dummy: (*Dummy)(t)}
+
+	if t.URL != nil {
+		tmp.URL = t.URL.String()
+	}
+
+	return json.Marshal(tmp)
+}
+
+// Domain returns the host name of the story's URL without any leading "www.", or an empty string for self-posts.
+func (t *Story) Domain() string {
+	if t.URL == nil {
+		return ""
+	}
+	return strings.TrimPrefix(t.URL.Hostname(), "www.")
+}
+
+// Find returns the node with the given id within the thread rooted at t.
+func (t *Story) Find(id int) (*Story, bool) {
+	var res *Story
+	dfs(nil, t, func(root, cur *Story) {
+		if res == nil && cur.Id == id {
+			res = cur
+		}
+	})
+	return res, res != nil
+}
+
+// Comments returns all descendants of t in depth-first order.
+func (t *Story) Comments() []*Story {
+	var res []*Story
+	dfs(nil, t, func(root, cur *Story) {
+		if cur != t {
+			res = append(res, cur)
+		}
+	})
+	return res
+}
+
+// depth returns the distance between t and the root of its thread.
+func (t *Story) depth() int {
+	n := 0
+	for p := t.parent; p != nil; p = p.parent {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,95 @@
+package hn
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// WidthMode determines how the width of comment text is chosen.
+type WidthMode int
+
+const (
+	// WidthFixed wraps text at a fixed width, or at the edge of the window if it is too narrow.
+	WidthFixed WidthMode = iota
+	// WidthFill wraps text at the edge of the window.
+	WidthFill
+)
+
+// WidthPolicy determines the width at which comment text is wrapped. The width is that of the text itself, i.e
+// excluding indentation.
+type WidthPolicy struct {
+	Mode WidthMode
+	// The fixed width, unused by `WidthFill`.
+	Width int
+}
+
+// DefaultWidthPolicy wraps text at 72 columns.
+var DefaultWidthPolicy = WidthPolicy{Mode: WidthFixed, Width: 72}
+
+// DefaultMaxIndent is the depth beyond which comments are not indented any further.
+const DefaultMaxIndent = 12
+
+// minTextWidth is the width below which text is never wrapped, regardless of the policy and window size.
+const minTextWidth = 20
+
+// ParseWidthPolicy parses a width policy, which is either a fixed width (e.g "72") or "fill".
+func ParseWidthPolicy(s string) (WidthPolicy, error) {
+	if s == "fill" {
+		return WidthPolicy{Mode: WidthFill}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return WidthPolicy{}, fmt.Errorf("invalid width: %q", s)
+	}
+	return WidthPolicy{Mode: WidthFixed, Width: n}, nil
+}
+
+// WithWidthPolicy sets the policy determining the width at which text is wrapped, see `Renderer.TextWidth`.
+func WithWidthPolicy(p WidthPolicy) RendererOption {
+	return func(r *Renderer) {
+		r.width = p
+	}
+}
+
+// WithMaxIndent sets the depth beyond which comments are not indented any further. The depth of such comments is shown
+// as a number instead. A depth of 0 disables the limit.
+func WithMaxIndent(n int) RendererOption {
+	return func(r *Renderer) {
+		r.maxIndent = n
+	}
+}
+
+// indentDepth returns the number of indentation levels of a node at the given depth.
+func (r *Renderer) indentDepth(depth int) int {
+	if r.maxIndent > 0 {
+		return min(depth, r.maxIndent)
+	}
+	return depth
+}
+
+// indentWidth returns the width taken up by the indentation of a node at the given depth.
+func (r *Renderer) indentWidth(depth int) int {
+	w := r.indentDepth(depth) * 2
+	if r.plain {
+		// Account for the selection marker.
+		w += 2
+	}
+	return w
+}
+
+// TextWidth returns the width at which the text of a node at the given depth is wrapped, given the width of the
+// window. A window width of 0 denotes an unknown width.
+func (r *Renderer) TextWidth(window, depth int) int {
+	avail := window - r.indentWidth(depth)
+	if window == 0 {
+		avail = r.width.Width
+	}
+	w := avail
+	if r.width.Mode == WidthFixed {
+		w = min(avail, r.width.Width)
+	}
+	if w <= 0 {
+		w = DefaultWidthPolicy.Width
+	}
+	return max(w, minTextWidth)
+}
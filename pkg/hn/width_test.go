@@ -0,0 +1,107 @@
+package hn
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+func TestParseWidthPolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want WidthPolicy
+		err  bool
+	}{
+		{in: "72", want: WidthPolicy{Mode: WidthFixed, Width: 72}},
+		{in: "fill", want: WidthPolicy{Mode: WidthFill}},
+		{in: "0", err: true},
+		{in: "-5", err: true},
+		{in: "fill:80", err: true},
+		{in: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWidthPolicy(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseWidthPolicy(%q): expected an error", tt.in)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseWidthPolicy(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	fixed := WidthPolicy{Mode: WidthFixed, Width: 72}
+	fill := WidthPolicy{Mode: WidthFill}
+	tests := []struct {
+		name          string
+		policy        WidthPolicy
+		maxIndent     int
+		plain         bool
+		window, depth int
+		want          int
+	}{
+		{name: "fixed", policy: fixed, window: 200, want: 72},
+		{name: "fixed in narrow window", policy: fixed, window: 50, depth: 2, want: 46},
+		{name: "fixed in unknown window", policy: fixed, window: 0, want: 72},
+		{name: "fill", policy: fill, window: 200, depth: 1, want: 198},
+		{name: "fill in unknown window", policy: fill, window: 0, want: DefaultWidthPolicy.Width},
+		{name: "minimum", policy: fill, window: 30, depth: 10, want: minTextWidth},
+		{name: "indent limit", policy: fill, maxIndent: 3, window: 100, depth: 10, want: 94},
+		{name: "selection marker", policy: fill, plain: true, window: 100, depth: 1, want: 96},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRenderer(Themes[DefaultTheme], tt.plain, WithWidthPolicy(tt.policy), WithMaxIndent(tt.maxIndent))
+			if got := r.TextWidth(tt.window, tt.depth); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapSpans(t *testing.T) {
+	bold := lipgloss.NewStyle().Bold(true)
+	tests := []struct {
+		name  string
+		spans []span
+		width int
+		want  []string
+	}{
+		{"empty", nil, 10, []string{""}},
+		{"fits", []span{{text: "hello world"}}, 11, []string{"hello world"}},
+		{"break at space", []span{{text: "hello world"}}, 10, []string{"hello", "world"}},
+		{"several spaces", []span{{text: "a  b   c"}}, 4, []string{"a  b", "c"}},
+		{"newline", []span{{text: "a\nb"}}, 10, []string{"a", "b"}},
+		{"empty line", []span{{text: "a\n\nb"}}, 10, []string{"a", "", "b"}},
+		{"long word", []span{{text: "abcdefghij"}}, 4, []string{"abcd", "efgh", "ij"}},
+		{"long word after text", []span{{text: "ab cdefghij"}}, 4, []string{"ab", "cdef", "ghij"}},
+		{"styled word", []span{{text: "a "}, {text: "bold", style: &bold}, {text: " c"}}, 6, []string{"a bold", "c"}},
+		{"word across spans", []span{{text: "ab"}, {text: "cd", style: &bold}, {text: " e"}}, 4, []string{"abcd", "e"}},
+		{"wide characters", []span{{text: "日本語の文"}}, 4, []string{"日本", "語の", "文"}},
+		{"zero width", []span{{text: "ab"}}, 0, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range wrapSpans(tt.spans, tt.width) {
+				s := ""
+				for _, sp := range line {
+					s += sp.text
+				}
+				if w := runewidth.StringWidth(s); w > max(tt.width, 1) {
+					t.Errorf("line %q is %d cells wide, want at most %d", s, w, tt.width)
+				}
+				got = append(got, s)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
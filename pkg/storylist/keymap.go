@@ -0,0 +1,35 @@
+package storylist
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type KeyMap struct {
+	// Move selection up/down by one story.
+	Up   key.Binding
+	Down key.Binding
+	// Move selection by one page.
+	PageUp   key.Binding
+	PageDown key.Binding
+	// Jump to first/last story.
+	Top    key.Binding
+	Bottom key.Binding
+	// Open the comments of the selected story.
+	Open key.Binding
+	// Quit out of view.
+	Quit key.Binding
+}
+
+// DefaultKeyMap returns the default key bindings for a new storylist model.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:       key.NewBinding(key.WithKeys("k", "up")),
+		Down:     key.NewBinding(key.WithKeys("j", "down")),
+		PageUp:   key.NewBinding(key.WithKeys("u", "ctrl+u", "pgup")),
+		PageDown: key.NewBinding(key.WithKeys("d", "ctrl+d", "pgdown")),
+		Top:      key.NewBinding(key.WithKeys("g", "home")),
+		Bottom:   key.NewBinding(key.WithKeys("G", "end")),
+		Open:     key.NewBinding(key.WithKeys("enter", "l")),
+		Quit:     key.NewBinding(key.WithKeys("q")),
+	}
+}
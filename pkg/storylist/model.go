@@ -0,0 +1,264 @@
+package storylist
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mergestat/timediff"
+	"github.com/toalaah/hn/pkg/hn"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Model is a selectable list of stories. Selecting a story does not open it by itself, instead an `OpenMsg` is issued
+// which the embedding program is expected to handle.
+type Model struct {
+	KeyMap KeyMap
+
+	title      string
+	stories    []*hn.Story
+	cursor     int
+	offset     int
+	width      int
+	height     int
+	lastStatus string
+	styles     Styles
+	marker     string
+}
+
+// Styles defines the appearance of the list.
+type Styles struct {
+	Title    lipgloss.Style
+	Metadata lipgloss.Style
+	Selected lipgloss.Style
+	Status   lipgloss.Style
+}
+
+// DefaultStyles returns the default styles for a new storylist model.
+func DefaultStyles() Styles {
+	return Styles{
+		Title:    lipgloss.NewStyle().Bold(true),
+		Metadata: lipgloss.NewStyle().Faint(true),
+		Selected: lipgloss.NewStyle().Background(lipgloss.Color("4")),
+		Status:   lipgloss.NewStyle().Background(lipgloss.Color("0")),
+	}
+}
+
+// OpenMsg is issued when the user requests to open a story.
+type OpenMsg struct {
+	Story *hn.Story
+}
+
+// entryHeight is the number of lines occupied by a single story.
+const entryHeight = 2
+
+func New(title string, stories []*hn.Story, opts ...Option) *Model {
+	m := &Model{
+		KeyMap:  DefaultKeyMap(),
+		title:   title,
+		stories: stories,
+		styles:  DefaultStyles(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// SetStories replaces the list's contents, resetting the selection.
+func (m *Model) SetStories(stories []*hn.Story) {
+	m.stories = stories
+	m.cursor = 0
+	m.offset = 0
+}
+
+// SetStatus overrides the status bar with the given text. An empty string restores the default status.
+func (m *Model) SetStatus(s string) {
+	m.lastStatus = s
+}
+
+// Selected returns the currently selected story, if any.
+func (m *Model) Selected() (*hn.Story, bool) {
+	if len(m.stories) == 0 {
+		return nil, false
+	}
+	return m.stories[m.cursor], true
+}
+
+func (m *Model) Init() tea.Cmd { return nil }
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.handleInput(msg)
+	case tea.MouseMsg:
+		switch tea.MouseEvent(msg).Button {
+		case tea.MouseButtonWheelUp:
+			m.move(-1)
+		case tea.MouseButtonWheelDown:
+			m.move(1)
+		case tea.MouseButtonLeft:
+			if msg.Action == tea.MouseActionPress {
+				m.cursor = clamp(m.offset+msg.Y/entryHeight, 0, len(m.stories)-1)
+				m.scrollToCursor()
+			}
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scrollToCursor()
+	}
+	return m, nil
+}
+
+func (m *Model) handleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		m.move(-1)
+	case key.Matches(msg, m.KeyMap.Down):
+		m.move(1)
+	case key.Matches(msg, m.KeyMap.PageUp):
+		m.move(-m.pageSize())
+	case key.Matches(msg, m.KeyMap.PageDown):
+		m.move(m.pageSize())
+	case key.Matches(msg, m.KeyMap.Top):
+		m.move(-len(m.stories))
+	case key.Matches(msg, m.KeyMap.Bottom):
+		m.move(len(m.stories))
+	case key.Matches(msg, m.KeyMap.Open):
+		if t, ok := m.Selected(); ok {
+			return m, func() tea.Msg { return OpenMsg{Story: t} }
+		}
+	case key.Matches(msg, m.KeyMap.Quit):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// pageSize returns the number of stories fitting on screen, leaving room for the status bar.
+func (m *Model) pageSize() int {
+	return max(1, (m.height-1)/entryHeight)
+}
+
+func (m *Model) move(n int) {
+	if len(m.stories) == 0 {
+		return
+	}
+	m.cursor = clamp(m.cursor+n, 0, len(m.stories)-1)
+	m.scrollToCursor()
+}
+
+func (m *Model) scrollToCursor() {
+	page := m.pageSize()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	m.offset = clamp(m.offset, 0, max(0, len(m.stories)-page))
+}
+
+func (m *Model) View() string {
+	var lines []string
+	end := min(m.offset+m.pageSize(), len(m.stories))
+	for i := m.offset; i < end; i++ {
+		entry := m.entryView(i)
+		if i == m.cursor {
+			entry = m.styles.Selected.Width(m.width).Render(entry)
+		}
+		lines = append(lines, entry)
+	}
+	content := strings.Join(lines, "\n")
+	// Pad so that the status bar is always at the bottom of the screen.
+	if h := lipgloss.Height(content); m.height > 1 && h < m.height-1 {
+		content += strings.Repeat("\n", m.height-1-h)
+	}
+	footer := cmp.Or(m.lastStatus, m.defaultStatus())
+	return content + "\n" + footer
+}
+
+// entryView renders the i-th entry. Both of its lines are cut to the width of the list, such that it always occupies
+// exactly `entryHeight` lines.
+func (m *Model) entryView(i int) string {
+	var (
+		t      = m.stories[i]
+		prefix = m.markerView(i) + fmt.Sprintf("%3d. ", i+1)
+		indent = lipgloss.Width(prefix)
+		// The width is unknown until the first `tea.WindowSizeMsg`, in which case nothing is cut.
+		avail = m.width - indent
+		title string
+		meta  string
+	)
+	if m.width > 0 {
+		avail = max(avail, 1)
+	}
+	if t.Title == "" && t.StoryTitle != "" {
+		// Comments have no title of their own, so show the beginning of their text instead.
+		text, _, _ := strings.Cut(strings.TrimSpace(t.Text()), "\n")
+		title = truncateText(text, avail)
+		meta = fmt.Sprintf("by %s %s | on: %s", t.Author, timediff.TimeDiff(t.Date), t.StoryTitle)
+	} else {
+		title = m.styles.Title.Render(truncateText(t.Title, avail))
+		if d := t.Domain(); d != "" {
+			// The domain is left out if it does not fit next to the title.
+			domain := fmt.Sprintf(" (%s)", d)
+			if m.width <= 0 || lipgloss.Width(t.Title+domain) <= avail {
+				title += m.styles.Metadata.Render(domain)
+			}
+		}
+		meta = fmt.Sprintf("%d points by %s %s | %d comments",
+			t.Points,
+			t.Author,
+			timediff.TimeDiff(t.Date),
+			t.Descendants,
+		)
+	}
+	meta = truncateText(meta, avail)
+	return prefix + title + "\n" + strings.Repeat(" ", indent) + m.styles.Metadata.Render(meta)
+}
+
+// markerView returns the selection marker column of the i-th entry, if a marker is set.
+func (m *Model) markerView(i int) string {
+	if i == m.cursor {
+		return m.marker
+	}
+	return strings.Repeat(" ", lipgloss.Width(m.marker))
+}
+
+func (m *Model) defaultStatus() string {
+	right := fmt.Sprintf("%s %d/%d", m.title, min(m.cursor+1, len(m.stories)), len(m.stories))
+	left := fmt.Sprintf(
+		"%s:Down %s:Up %s:Open %s:Quit",
+		strings.Join(m.KeyMap.Down.Keys(), ","),
+		strings.Join(m.KeyMap.Up.Keys(), ","),
+		strings.Join(m.KeyMap.Open.Keys(), ","),
+		strings.Join(m.KeyMap.Quit.Keys(), ","),
+	)
+	padding := max(m.width-lipgloss.Width(right)-lipgloss.Width(left), 0)
+	return m.styles.Status.Render(left + strings.Repeat(" ", padding) + right)
+}
+
+type Option func(*Model)
+
+func WithKeys(k KeyMap) Option {
+	return func(m *Model) {
+		m.KeyMap = k
+	}
+}
+
+func WithStyles(s Styles) Option {
+	return func(m *Model) {
+		m.styles = s
+	}
+}
+
+// WithMarker sets a marker displayed in front of the selected story, for terminals unable to display the selection's
+// style.
+func WithMarker(s string) Option {
+	return func(m *Model) {
+		m.marker = s
+	}
+}
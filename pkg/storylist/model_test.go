@@ -0,0 +1,42 @@
+package storylist
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/toalaah/hn/pkg/hn"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestViewFitsWidth(t *testing.T) {
+	u, _ := url.Parse("https://example.com/article")
+	stories := []*hn.Story{
+		{Id: 1, Title: strings.Repeat("A very long title ", 10), URL: u, Author: "someone", Points: 1234},
+		{Id: 2, Title: "Short", Author: strings.Repeat("x", 80)},
+		{Id: 3, Title: "Short", URL: u},
+	}
+	const width, height = 60, 10
+	m := New("top", stories, WithMarker("> "))
+	m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != height {
+		t.Errorf("got %d lines, want %d", len(lines), height)
+	}
+	for i, l := range lines {
+		if w := lipgloss.Width(l); w > width {
+			t.Errorf("line %d is %d cells wide, want at most %d: %q", i, w, width, l)
+		}
+	}
+	for i := range stories {
+		if h := lipgloss.Height(m.entryView(i)); h != entryHeight {
+			t.Errorf("entry %d is %d lines high, want %d", i, h, entryHeight)
+		}
+	}
+	if !strings.Contains(lines[4], "(example.com)") {
+		t.Errorf("domain missing from short title: %q", lines[4])
+	}
+}
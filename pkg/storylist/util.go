@@ -0,0 +1,18 @@
+package storylist
+
+import (
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/exp/constraints"
+)
+
+func clamp[T constraints.Ordered](x, lo, hi T) T {
+	return max(lo, min(x, hi))
+}
+
+// truncateText shortens s to at most width cells, marking truncation with an ellipsis.
+func truncateText(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return truncate.StringWithTail(s, uint(width), "...")
+}
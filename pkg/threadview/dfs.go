@@ -13,3 +13,11 @@ func NumNodes(t Thread) int {
 	dfs(nil, t, func(root, cur Thread) { n++ })
 	return n
 }
+
+// walk calls f for t and all of its descendants which are part of the index, see `Model.children`.
+func (m *Model) walk(t Thread, f func(cur Thread)) {
+	f(t)
+	for _, c := range m.children(t) {
+		m.walk(c, f)
+	}
+}
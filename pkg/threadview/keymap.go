@@ -1,6 +1,10 @@
 package threadview
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+
 	"github.com/charmbracelet/bubbles/key"
 )
 
@@ -26,6 +30,20 @@ type KeyMap struct {
 	ResetView key.Binding
 	// Issue copy command to current thread.
 	Copy key.Binding
+	// List the links of the current thread, to open or copy them.
+	Links key.Binding
+	// Start searching forward/backward through the thread.
+	Search         key.Binding
+	SearchBackward key.Binding
+	// Jump to next/previous search match, relative to the search direction.
+	NextMatch key.Binding
+	PrevMatch key.Binding
+	// Clear the current search and its highlights.
+	ClearSearch key.Binding
+	// Jump to next unread thread.
+	NextUnread key.Binding
+	// Re-fetch the thread to pick up new comments.
+	Refresh key.Binding
 	// Quit out of view.
 	Quit key.Binding
 }
@@ -33,18 +51,76 @@ type KeyMap struct {
 // DefaultKeyMap returns the default key bindings for a new threadview model.
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Up:         key.NewBinding(key.WithKeys("k")),
-		Down:       key.NewBinding(key.WithKeys("j")),
-		PageUp:     key.NewBinding(key.WithKeys("u", "ctrl+u")),
-		PageDown:   key.NewBinding(key.WithKeys("d", "ctrl+d")),
-		Top:        key.NewBinding(key.WithKeys("g")),
-		Bottom:     key.NewBinding(key.WithKeys("G")),
-		Next:       key.NewBinding(key.WithKeys("n")),
-		Prev:       key.NewBinding(key.WithKeys("p")),
-		Root:       key.NewBinding(key.WithKeys("r")),
-		ToggleFold: key.NewBinding(key.WithKeys("tab")),
-		ResetView:  key.NewBinding(key.WithKeys("z")),
-		Copy:       key.NewBinding(key.WithKeys("y")),
-		Quit:       key.NewBinding(key.WithKeys("h", "q")),
+		Up:             key.NewBinding(key.WithKeys("k")),
+		Down:           key.NewBinding(key.WithKeys("j")),
+		PageUp:         key.NewBinding(key.WithKeys("u", "ctrl+u")),
+		PageDown:       key.NewBinding(key.WithKeys("d", "ctrl+d")),
+		Top:            key.NewBinding(key.WithKeys("g")),
+		Bottom:         key.NewBinding(key.WithKeys("G")),
+		Next:           key.NewBinding(key.WithKeys("n")),
+		Prev:           key.NewBinding(key.WithKeys("p")),
+		Root:           key.NewBinding(key.WithKeys("r")),
+		ToggleFold:     key.NewBinding(key.WithKeys("tab")),
+		ResetView:      key.NewBinding(key.WithKeys("z")),
+		Copy:           key.NewBinding(key.WithKeys("y")),
+		Links:          key.NewBinding(key.WithKeys("o")),
+		Search:         key.NewBinding(key.WithKeys("/")),
+		SearchBackward: key.NewBinding(key.WithKeys("?")),
+		NextMatch:      key.NewBinding(key.WithKeys("ctrl+n")),
+		PrevMatch:      key.NewBinding(key.WithKeys("ctrl+p")),
+		ClearSearch:    key.NewBinding(key.WithKeys("esc")),
+		NextUnread:     key.NewBinding(key.WithKeys("U")),
+		Refresh:        key.NewBinding(key.WithKeys("R")),
+		Quit:           key.NewBinding(key.WithKeys("h", "q")),
+	}
+}
+
+// Named returns pointers to all bindings of the key map, keyed by the name used to refer to them in configuration files.
+func (k *KeyMap) Named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":              &k.Up,
+		"down":            &k.Down,
+		"page_up":         &k.PageUp,
+		"page_down":       &k.PageDown,
+		"top":             &k.Top,
+		"bottom":          &k.Bottom,
+		"next":            &k.Next,
+		"prev":            &k.Prev,
+		"root":            &k.Root,
+		"toggle_fold":     &k.ToggleFold,
+		"reset_view":      &k.ResetView,
+		"copy":            &k.Copy,
+		"links":           &k.Links,
+		"search":          &k.Search,
+		"search_backward": &k.SearchBackward,
+		"next_match":      &k.NextMatch,
+		"prev_match":      &k.PrevMatch,
+		"clear_search":    &k.ClearSearch,
+		"next_unread":     &k.NextUnread,
+		"refresh":         &k.Refresh,
+		"quit":            &k.Quit,
+	}
+}
+
+// Validate reports an error if any key is bound to more than one action.
+func (k *KeyMap) Validate() error {
+	named := k.Named()
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	owner := map[string]string{}
+	for _, name := range names {
+		for _, key := range named[name].Keys() {
+			if other, ok := owner[key]; ok && other != name {
+				errs = append(errs, fmt.Errorf("key %q is bound to both %s and %s", key, other, name))
+				continue
+			}
+			owner[key] = name
+		}
 	}
+	return errors.Join(errs...)
 }
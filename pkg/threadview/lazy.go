@@ -0,0 +1,95 @@
+package threadview
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// unloaded reports whether t has children which have not been loaded yet.
+func unloaded(t Thread) bool {
+	l, ok := t.(LazyThread)
+	return ok && !l.Loaded() && l.NumChildren() > 0
+}
+
+// loadChildren starts loading the children of the node at index i, showing a placeholder until they arrive.
+func (m *Model) loadChildren(i int) tea.Cmd {
+	md := &m.meta[i]
+	if md.loading {
+		return nil
+	}
+	md.loading = true
+	md.cached, md.heightValid = false, false
+	return md.node.(LazyThread).LoadChildren()
+}
+
+func (m *Model) childrenLoaded(msg ChildrenLoadedMsg) tea.Cmd {
+	i, ok := m.index[msg.ID]
+	if !ok {
+		return nil
+	}
+	md := &m.meta[i]
+	md.node.Update(msg)
+	md.loading = false
+	if msg.Err != nil {
+		md.cached, md.heightValid = false, false
+		md.collapsed = true
+		m.lastStatus = fmt.Sprintf("Failed to load replies: %s", msg.Err.Error())
+		return ClearStatusAfter(1250 * time.Millisecond)
+	}
+	m.treeChanged(md.node, func() {})
+	return nil
+}
+
+// children returns the children of t, leaving out those which are not part of the index, see `rebuild`.
+func (m *Model) children(t Thread) []Thread {
+	return slices.DeleteFunc(slices.Clone(t.Children()), func(c Thread) bool {
+		i, ok := m.index[c.ID()]
+		return !ok || m.meta[i].node != c
+	})
+}
+
+// loadingView renders the placeholder shown in place of the children of a node whose children are being loaded.
+func (m *Model) loadingView(t Thread, state DisplayStateMsg) string {
+	n := 0
+	if l, ok := t.(LazyThread); ok {
+		n = l.NumChildren()
+	}
+	return m.loadingStyle.Render(fmt.Sprintf("%*sLoading %d replies...", state.Depth*2, "", n))
+}
+
+// rebuild recomputes the node metadata after the tree has changed. Nodes which are still part of the tree, as
+// identified by their ID, keep their state. Nodes sharing their ID with a node further up can not be told apart from
+// it, so they are left out along with their descendants, see `children`.
+func (m *Model) rebuild() {
+	old, oldIndex := m.meta, m.index
+	m.meta = make([]metadata, 0, len(old))
+	m.index = make(map[int]int, len(old))
+	var walk func(t Thread)
+	walk = func(t Thread) {
+		if _, ok := m.index[t.ID()]; ok {
+			return
+		}
+		md := metadata{node: t, visible: true, collapsed: unloaded(t)}
+		if i, ok := oldIndex[t.ID()]; ok {
+			md = old[i]
+			if md.node != t {
+				md.node, md.cached, md.heightValid = t, false, false
+			}
+		}
+		m.index[t.ID()] = len(m.meta)
+		m.meta = append(m.meta, md)
+		for _, c := range t.Children() {
+			walk(c)
+		}
+	}
+	walk(m.head)
+	m.numNodes = len(m.meta)
+	// Offsets are only known again after the next layout.
+	m.order = nil
+	if m.search.query != "" {
+		m.updateMatches()
+	}
+}
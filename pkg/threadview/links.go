@@ -0,0 +1,122 @@
+package threadview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Link is a hyperlink contained in a thread.
+type Link struct {
+	// The text the link is displayed as, which may be abbreviated.
+	Text string
+	URL  string
+}
+
+// Linker is implemented by threads containing links, which may then be opened or copied through the link picker.
+// Threads not implementing this interface contain no links.
+type Linker interface {
+	// Links returns the links of the thread itself, excluding those of its children, in display order.
+	Links() []Link
+}
+
+// OpenLinkMsg is sent to the selected node when a link is chosen in the link picker. The node should respond with an
+// `OpenLinkResultMsg`.
+type OpenLinkMsg struct{ URL string }
+type OpenLinkResultMsg struct{ Error error }
+
+type linkPicker struct {
+	active bool
+	links  []Link
+	cursor int
+}
+
+// openLinkPicker lists the links of the selected node, if any.
+func (m *Model) openLinkPicker() tea.Cmd {
+	var links []Link
+	if l, ok := m.curRoot.(Linker); ok {
+		links = l.Links()
+	}
+	if len(links) == 0 {
+		m.lastStatus = "No links in comment"
+		return ClearStatusAfter(1250 * time.Millisecond)
+	}
+	m.links = linkPicker{active: true, links: links}
+	return nil
+}
+
+// handleLinkInput handles key presses while the link picker is open.
+func (m *Model) handleLinkInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.links
+	switch {
+	case msg.Type == tea.KeyEnter:
+		return m, m.openLink(p.cursor)
+	case key.Matches(msg, m.KeyMap.Copy):
+		p.active = false
+		_, cmd := m.curRoot.Update(CopyTextMsg{Text: p.links[p.cursor].URL})
+		return m, cmd
+	case key.Matches(msg, m.KeyMap.Up), msg.Type == tea.KeyUp:
+		p.cursor = max(p.cursor-1, 0)
+	case key.Matches(msg, m.KeyMap.Down), msg.Type == tea.KeyDown:
+		p.cursor = min(p.cursor+1, len(p.links)-1)
+	case key.Matches(msg, m.KeyMap.Quit, m.KeyMap.ClearSearch, m.KeyMap.Links):
+		p.active = false
+	case msg.Type == tea.KeyRunes && len(msg.Runes) == 1 && msg.Runes[0] >= '1' && msg.Runes[0] <= '9':
+		if i := int(msg.Runes[0] - '1'); i < len(p.links) {
+			return m, m.openLink(i)
+		}
+	}
+	return m, nil
+}
+
+// openLink closes the link picker and opens its i-th link.
+func (m *Model) openLink(i int) tea.Cmd {
+	m.links.active = false
+	_, cmd := m.curRoot.Update(OpenLinkMsg{URL: m.links.links[i].URL})
+	return cmd
+}
+
+// linkPickerView renders the numbered list of links in place of the thread, scrolled such that the cursor is visible.
+func (m *Model) linkPickerView() string {
+	p := &m.links
+	height := max(m.viewport.Height, 1)
+	offset := max(p.cursor-height+1, 0)
+	cursor := lipgloss.NewStyle().Reverse(true)
+
+	lines := make([]string, height)
+	for i := range height {
+		j := offset + i
+		if j >= len(p.links) {
+			break
+		}
+		l := p.links[j]
+		line := fmt.Sprintf("%3d. %s", j+1, l.URL)
+		// HN abbreviates the text of long links, which is only worth showing if it differs from the target.
+		if l.Text != "" && l.Text != l.URL && !strings.HasPrefix(l.URL, strings.TrimSuffix(l.Text, "...")) {
+			line += fmt.Sprintf(" (%s)", l.Text)
+		}
+		if j == p.cursor {
+			line = cursor.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) linkPickerStatus() string {
+	left := fmt.Sprintf(
+		"enter,1-9:Open %s:Copy %s:Close",
+		strings.Join(m.KeyMap.Copy.Keys(), ","),
+		strings.Join(m.KeyMap.Quit.Keys(), ","),
+	)
+	right := fmt.Sprintf("link %d/%d", m.links.cursor+1, len(m.links.links))
+	padding := max(m.viewport.Width-lipgloss.Width(right)-lipgloss.Width(left), 0)
+	return m.statusStyle.Render(left + strings.Repeat(" ", padding) + right)
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,6 +26,17 @@ type Model struct {
 	hideCollapsedChildren bool
 	numNodes              int
 	meta                  []metadata
+	index                 map[int]int
+	order                 []int
+	contentHeight         int
+	quitCmd               tea.Cmd
+	seekPending           bool
+	anchor                scrollAnchor
+	watch                 watch
+	search                search
+	links                 linkPicker
+	statusStyle           lipgloss.Style
+	loadingStyle          lipgloss.Style
 }
 
 type metadata struct {
@@ -32,6 +44,17 @@ type metadata struct {
 	collapsed bool
 	visible   bool
 	height    int
+	// Whether the node's children are being loaded, see `LazyThread`.
+	loading bool
+	// Display state and line offset as of the last layout.
+	state  DisplayStateMsg
+	offset int
+	// The cached view and the state it was rendered in, and the state the height was determined in.
+	view        string
+	viewState   DisplayStateMsg
+	cached      bool
+	heightKey   layoutKey
+	heightValid bool
 }
 
 // DisplayStateMsg is used to inform a node of its current state. The node is always notified of its state prior to calling its `View()` method.
@@ -42,10 +65,14 @@ type DisplayStateMsg struct {
 	Selected bool
 	// Whether this node is a descendent of the currently selected node.
 	Subthread bool
+	// The depth of the currently selected node, if Subthread is set.
+	SubthreadDepth int
 	// The depth of this node, from the thread root.
 	Depth int
 	// The current width of the threadview's viewport.
 	Width int
+	// The active search query, if any. Nodes should highlight occurrences as determined by `FindMatches`.
+	Highlight string
 }
 
 func New(t Thread, opts ...Option) (*Model, error) {
@@ -61,25 +88,38 @@ func New(t Thread, opts ...Option) (*Model, error) {
 		headSelectable: true,
 		numNodes:       n,
 		meta:           make([]metadata, n),
+		index:          make(map[int]int, n),
+		quitCmd:        tea.Quit,
+		search:         newSearch(),
+		statusStyle:    lipgloss.NewStyle().Background(lipgloss.Color("0")),
+		loadingStyle:   lipgloss.NewStyle().Faint(true),
 	}
 
 	i := 0
+	var err error
 	dfs(nil, m.head, func(root, cur Thread) {
+		if _, ok := m.index[cur.ID()]; ok && err == nil {
+			err = fmt.Errorf("duplicate node id %d", cur.ID())
+		}
 		m.meta[i] = metadata{
 			visible:   true,
 			node:      cur,
-			collapsed: false,
+			collapsed: unloaded(cur),
 			height:    0,
 		}
+		m.index[cur.ID()] = i
 		i++
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
 	if !m.headSelectable {
-		children := t.Children()
+		children := m.children(t)
 		if len(children) > 0 {
 			m.curRoot = children[0]
 		} else {
@@ -90,7 +130,7 @@ func New(t Thread, opts ...Option) (*Model, error) {
 	return m, nil
 }
 
-func (m *Model) Init() tea.Cmd { return nil }
+func (m *Model) Init() tea.Cmd { return tea.Batch(m.scheduleRefresh(), m.expireViews()) }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -100,7 +140,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleInput(msg)
 	case tea.MouseMsg:
 		if tea.MouseEvent(msg).Button == tea.MouseButtonLeft {
-			m.curRoot = m.getThreadFromYPos(msg.Y)
+			if t := m.getThreadFromYPos(msg.Y); t != m.head || m.headSelectable {
+				m.curRoot = t
+			}
 		}
 	case tea.WindowSizeMsg:
 		padding := 1
@@ -114,9 +156,39 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastStatus = fmt.Sprintf("Failed to copy to clipboard: %s", msg.Error.Error())
 		}
 		return m, ClearStatusAfter(1250 * time.Millisecond)
+	case OpenLinkResultMsg:
+		if msg.Error == nil {
+			m.lastStatus = "Opened link"
+		} else {
+			m.lastStatus = fmt.Sprintf("Failed to open link: %s", msg.Error.Error())
+		}
+		return m, ClearStatusAfter(1250 * time.Millisecond)
+	case ChildrenLoadedMsg:
+		return m, m.childrenLoaded(msg)
+	case RefreshedMsg:
+		return m, m.refreshed(msg)
+	case refreshTickMsg:
+		if msg.m == m && msg.gen == m.watch.gen {
+			return m, m.refresh(false)
+		}
+		return m, nil
+	case TreeChangedMsg:
+		m.treeChanged(nil, func() {})
+		return m, nil
+	case expireViewsMsg:
+		if msg.m != m {
+			return m, nil
+		}
+		m.invalidateFrom(nil)
+		return m, m.expireViews()
 	case ClearStatusMsg:
 		m.lastStatus = ""
 	}
+	if m.search.active {
+		var cmd tea.Cmd
+		m.search.input, cmd = m.search.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 	// Propagate messages.
 	v, cmd := m.viewport.Update(msg)
 	m.viewport = v
@@ -129,63 +201,54 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
-	threads := m.threadView(m.head, DisplayStateMsg{
-		Collapsed: m.meta[m.threadIndex(m.head)].collapsed,
-		Selected:  m.curRoot == m.head,
-		Subthread: false,
-		Depth:     0,
-		Width:     m.viewport.Width,
-	})
-	threads = strings.Trim(threads, "\n")
-	footer := cmp.Or(m.lastStatus, m.defaultStatus())
-	m.viewport.SetContent(threads)
-	return strings.Join([]string{m.viewport.View(), footer}, "\n")
-}
-
-func (m *Model) threadView(t Thread, state DisplayStateMsg) string {
-	var b strings.Builder
-
-	idx := m.threadIndex(t)
-	if !m.meta[idx].visible {
-		return ""
+	if m.links.active {
+		return m.linkPickerView() + "\n" + m.linkPickerStatus()
 	}
-
-	t.Update(state)
-	s := t.View()
-	if s == "" {
-		m.meta[idx].height = 0
-	} else {
-		b.WriteString(s)
-		m.meta[idx].height = lipgloss.Height(s)
+	m.layout()
+	footer := cmp.Or(m.lastStatus, m.defaultStatus())
+	if m.search.active {
+		footer = m.search.input.View()
 	}
-	b.WriteString("\n")
-
-	state.Depth++
-	state.Subthread = cmp.Or(state.Subthread, state.Selected)
-	visible := !(m.hideCollapsedChildren && state.Collapsed)
-	children := t.Children()
-	for _, c := range children {
-		cidx := m.threadIndex(c)
-		m.meta[cidx].visible = visible
-		state.Selected = c == m.curRoot
-		state.Collapsed = m.meta[cidx].collapsed
-		s := m.threadView(c, state)
-
-		b.WriteString(s)
+	// Scrolling depends on the total height of the content, so the viewport is updated with blank content first.
+	// Node heights are only known after layout, so deferred seeks are resolved here too.
+	m.viewport.SetContent(m.content(0, 0))
+	m.restoreAnchor()
+	if m.seekPending {
+		m.seekToCurrentRoot()
+		m.seekPending = false
 	}
-
-	return b.String()
+	m.viewport.SetContent(m.content(m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height))
+	return strings.Join([]string{m.viewport.View(), footer}, "\n")
 }
 
 func (m *Model) handleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	if m.search.active {
+		return m.handleSearchInput(msg)
+	}
+	if m.links.active {
+		return m.handleLinkInput(msg)
+	}
+	if key.Matches(msg, m.KeyMap.Quit) {
+		return m, m.quitCmd
+	}
+	if key.Matches(msg, m.KeyMap.Refresh) {
+		return m, m.refresh(true)
+	}
+	// Nothing is selectable, e.g the thread has no comments.
+	if m.curRoot == nil {
+		return m, nil
+	}
+	if ok, cmd := m.handleSearchKeys(msg); ok {
+		return m, cmd
+	}
 	switch {
 	case key.Matches(msg, m.KeyMap.Up):
 		if p, ok := m.curRoot.Parent(); ok && !(p == m.head && !m.headSelectable) {
 			m.curRoot = p
 		}
 	case key.Matches(msg, m.KeyMap.Down):
-		if c := m.curRoot.Children(); len(c) > 0 {
+		if c := m.children(m.curRoot); len(c) > 0 {
 			m.curRoot = c[0]
 		}
 	case key.Matches(msg, m.KeyMap.PageUp):
@@ -193,9 +256,9 @@ func (m *Model) handleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.KeyMap.PageDown):
 		m.viewport.ScrollDown(m.viewport.Height / 4)
 	case key.Matches(msg, m.KeyMap.Top):
-		m.curRoot = m.head.Children()[0]
+		m.curRoot = m.children(m.head)[0]
 	case key.Matches(msg, m.KeyMap.Bottom):
-		threads := m.head.Children()
+		threads := m.children(m.head)
 		m.curRoot = threads[len(threads)-1]
 	case key.Matches(msg, m.KeyMap.Next):
 		m.nextThread()
@@ -209,42 +272,60 @@ func (m *Model) handleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		i := m.threadIndex(m.curRoot)
 		c := m.meta[i].collapsed
 		m.meta[i].collapsed = !c
+		if c && unloaded(m.curRoot) {
+			cmds = append(cmds, m.loadChildren(i))
+		}
 		if m.hideCollapsedChildren {
-			dfs(nil, m.curRoot, func(root, cur Thread) {
+			m.walk(m.curRoot, func(cur Thread) {
 				m.meta[m.threadIndex(cur)].visible = c
 			})
 		}
+	case key.Matches(msg, m.KeyMap.NextUnread):
+		if !m.nextUnread() {
+			m.lastStatus = "No unread comments"
+			cmds = append(cmds, ClearStatusAfter(1250*time.Millisecond))
+		}
 	case key.Matches(msg, m.KeyMap.ResetView):
 		m.seekToCurrentRoot()
 	case key.Matches(msg, m.KeyMap.Copy):
 		_, cmd := m.curRoot.Update(CopyTextMsg{})
 		cmds = append(cmds, cmd)
-	case key.Matches(msg, m.KeyMap.Quit):
-		return m, tea.Quit
+	case key.Matches(msg, m.KeyMap.Links):
+		cmds = append(cmds, m.openLinkPicker())
 	}
 	return m, tea.Batch(cmds...)
 }
 
-func (m *Model) getYOffsetForThread(t Thread) int {
-	y := 0
-	for i := range m.threadIndex(t) {
-		y += m.meta[i].height
+// Select marks t as the currently selected node and scrolls it into view.
+func (m *Model) Select(t Thread) {
+	m.curRoot = t
+	m.seekPending = true
+}
+
+// SelectIndex selects the node at the given position in a depth-first traversal of the thread, as returned by e.g
+// `hn.Story.CommentIndex`. It reports whether the index was valid.
+func (m *Model) SelectIndex(i int) bool {
+	if i < 0 || i >= len(m.meta) || (i == 0 && !m.headSelectable) {
+		return false
 	}
-	return y
+	m.Select(m.meta[i].node)
+	return true
+}
+
+func (m *Model) getYOffsetForThread(t Thread) int {
+	return m.meta[m.threadIndex(t)].offset
 }
 
 func (m *Model) getThreadFromYPos(y int) Thread {
 	y = y + m.viewport.YOffset
-	lo, hi := 0, 0
-	for _, m := range m.meta {
-		if !m.visible {
-			continue
-		}
-		hi += m.height
-		if lo <= y && y < hi {
-			return m.node
+	// Nodes are laid out in display order, so the node containing y is the last one starting at or before it.
+	j, _ := slices.BinarySearchFunc(m.order, y, func(i, y int) int {
+		return cmp.Compare(m.meta[i].offset, y+1)
+	})
+	for ; j > 0; j-- {
+		if md := &m.meta[m.order[j-1]]; md.height > 0 {
+			return md.node
 		}
-		lo = hi
 	}
 	// Should never happen
 	return m.curRoot
@@ -264,7 +345,7 @@ func (m *Model) getParentOrTopThread(t Thread) Thread {
 
 func (m *Model) navigateSubThread(n int) {
 	p := m.getParentOrTopThread(m.curRoot)
-	children := p.Children()
+	children := m.children(p)
 	i := -1
 	for j := range children {
 		if children[j] == m.curRoot {
@@ -279,11 +360,30 @@ func (m *Model) navigateSubThread(n int) {
 	m.curRoot = children[clamp(i+n, 0, l-1)]
 }
 
+// nextUnread selects the next unread node after the current selection, wrapping around at the end of the thread. It
+// reports whether such a node exists.
+func (m *Model) nextUnread() bool {
+	cur := m.threadIndex(m.curRoot)
+	for j := 1; j < len(m.meta); j++ {
+		i := (cur + j) % len(m.meta)
+		if u, ok := m.meta[i].node.(Unreader); ok && u.Unread() {
+			t := m.meta[i].node
+			m.expandTo(t)
+			m.Select(t)
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Model) nextThread() { m.navigateSubThread(1) }
 func (m *Model) prevThread() { m.navigateSubThread(-1) }
 
 type ClearStatusMsg struct{}
-type CopyTextMsg struct{}
+
+// CopyTextMsg requests the node to copy its contents to the clipboard, or Text if set. The node should respond with a
+// `CopyTextResultMsg`.
+type CopyTextMsg struct{ Text string }
 type CopyTextResultMsg struct{ Error error }
 
 func ClearStatusAfter(d time.Duration) tea.Cmd {
@@ -298,13 +398,16 @@ func (m *Model) defaultStatus() string {
 	if !m.headSelectable {
 		numNodes--
 	}
-	right := fmt.Sprintf("(%d rows) #%d %d/%d %d%%",
-		m.meta[m.threadIndex(m.curRoot)].height,
-		m.curRoot.ID(),
-		m.threadIndex(m.curRoot),
-		numNodes, // Head is "virtual"
-		int(m.viewport.ScrollPercent()*100),
-	)
+	right := fmt.Sprintf("0/0 %d%%", int(m.viewport.ScrollPercent()*100))
+	if m.curRoot != nil {
+		right = m.searchStatus() + fmt.Sprintf("(%d rows) #%d %d/%d %d%%",
+			m.meta[m.threadIndex(m.curRoot)].height,
+			m.curRoot.ID(),
+			m.threadIndex(m.curRoot),
+			numNodes, // Head is "virtual"
+			int(m.viewport.ScrollPercent()*100),
+		)
+	}
 	left := fmt.Sprintf(
 		"%s:Next %s:Prev %s:Down %s:Up %s:Fold %s:Quit",
 		strings.Join(m.KeyMap.Next.Keys(), ","),
@@ -315,15 +418,12 @@ func (m *Model) defaultStatus() string {
 		strings.Join(m.KeyMap.Quit.Keys(), ","),
 	)
 	padding := max(m.viewport.Width-lipgloss.Width(right)-lipgloss.Width(left), 0)
-	return lipgloss.NewStyle().Background(lipgloss.Color("0")).Render(left + strings.Repeat(" ", padding) + right)
+	return m.statusStyle.Render(left + strings.Repeat(" ", padding) + right)
 }
 
 func (m *Model) threadIndex(t Thread) int {
-	for i := range m.meta {
-		node := m.meta[i].node
-		if node != nil && node == t {
-			return i
-		}
+	if i, ok := m.index[t.ID()]; ok && m.meta[i].node == t {
+		return i
 	}
 	panic("could not determine thread index")
 }
@@ -347,3 +447,18 @@ func WithHideCollapsedChildren(b bool) Option {
 		m.hideCollapsedChildren = b
 	}
 }
+
+// WithQuitCmd sets the command issued when the quit binding is pressed. By default, the program is exited via `tea.Quit`.
+// This allows embedding the model in a larger program, for instance to return to a previous view instead.
+func WithQuitCmd(cmd tea.Cmd) Option {
+	return func(m *Model) {
+		m.quitCmd = cmd
+	}
+}
+
+// WithStatusStyle sets the style of the status bar.
+func WithStatusStyle(s lipgloss.Style) Option {
+	return func(m *Model) {
+		m.statusStyle = s
+	}
+}
@@ -0,0 +1,207 @@
+package threadview
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// scrollAnchor records the node at the top of the viewport, such that the view can be kept in place after the tree
+// has changed.
+type scrollAnchor struct {
+	pending bool
+	id      int
+	// The number of lines of the node scrolled past the top of the viewport.
+	delta int
+}
+
+// ReplaceSubtree replaces the node with the given ID, including its descendants, by t. Nodes of t sharing their ID
+// with a node of the replaced subtree keep their state.
+func (m *Model) ReplaceSubtree(id int, t Thread) error {
+	if t == nil {
+		return errors.New("thread is nil")
+	}
+	if err := m.checkIDs(t, id); err != nil {
+		return err
+	}
+	if id == m.head.ID() {
+		m.treeChanged(t, func() { m.head = t })
+		return nil
+	}
+	p, i, err := m.parentOf(id)
+	if err != nil {
+		return err
+	}
+	children := p.Children()
+	children[i] = t
+	m.treeChanged(p, func() { p.(MutableThread).SetChildren(children) })
+	return nil
+}
+
+// Insert adds t as the i-th child of the node with the given ID. A negative i appends t after all other children.
+func (m *Model) Insert(parentID, i int, t Thread) error {
+	if t == nil {
+		return errors.New("thread is nil")
+	}
+	p, err := m.mutableNode(parentID)
+	if err != nil {
+		return err
+	}
+	if err := m.checkIDs(t, 0); err != nil {
+		return err
+	}
+	children := p.Children()
+	if i < 0 {
+		i = len(children)
+	} else if i > len(children) {
+		return fmt.Errorf("index %d out of range for node %d with %d children", i, parentID, len(children))
+	}
+	children = slices.Insert(children, i, t)
+	m.treeChanged(p, func() { p.(MutableThread).SetChildren(children) })
+	return nil
+}
+
+// Remove removes the node with the given ID, including its descendants. The head of the thread can not be removed.
+func (m *Model) Remove(id int) error {
+	if id == m.head.ID() {
+		return errors.New("can not remove the head of the thread")
+	}
+	p, i, err := m.parentOf(id)
+	if err != nil {
+		return err
+	}
+	children := slices.Delete(p.Children(), i, i+1)
+	m.treeChanged(p, func() { p.(MutableThread).SetChildren(children) })
+	return nil
+}
+
+// checkIDs reports an error if any node of t shares its ID with a node of the tree, other than those of the subtree
+// rooted at the node with the given ID which is about to be replaced.
+func (m *Model) checkIDs(t Thread, replaced int) error {
+	replacing := map[int]bool{}
+	if i, ok := m.index[replaced]; ok && replaced != 0 {
+		dfs(nil, m.meta[i].node, func(_, cur Thread) { replacing[cur.ID()] = true })
+	}
+	var err error
+	dfs(nil, t, func(_, cur Thread) {
+		if _, ok := m.index[cur.ID()]; ok && !replacing[cur.ID()] && err == nil {
+			err = fmt.Errorf("a node with id %d already exists", cur.ID())
+		}
+	})
+	return err
+}
+
+// mutableNode returns the node with the given ID, given that it implements `MutableThread`.
+func (m *Model) mutableNode(id int) (Thread, error) {
+	i, ok := m.index[id]
+	if !ok {
+		return nil, fmt.Errorf("no node with id %d", id)
+	}
+	t := m.meta[i].node
+	if _, ok := t.(MutableThread); !ok {
+		return nil, fmt.Errorf("node %d does not support changing its children", id)
+	}
+	return t, nil
+}
+
+// parentOf returns the parent of the node with the given ID and the node's position among its siblings.
+func (m *Model) parentOf(id int) (Thread, int, error) {
+	i, ok := m.index[id]
+	if !ok {
+		return nil, 0, fmt.Errorf("no node with id %d", id)
+	}
+	t := m.meta[i].node
+	p, ok := t.Parent()
+	if !ok {
+		return nil, 0, fmt.Errorf("node %d has no parent", id)
+	}
+	if _, err := m.mutableNode(p.ID()); err != nil {
+		return nil, 0, err
+	}
+	j := slices.Index(p.Children(), t)
+	if j < 0 {
+		return nil, 0, fmt.Errorf("node %d is not a child of its parent", id)
+	}
+	return p, j, nil
+}
+
+// treeChanged applies the given change to the tree and updates the model accordingly. The selection, the collapse
+// state of the remaining nodes and the scroll position are kept. The views of at and its ancestors, which may show
+// the number of their descendants, are re-rendered, or those of all nodes if at is nil.
+func (m *Model) treeChanged(at Thread, change func()) {
+	if len(m.order) > 0 {
+		top := m.getThreadFromYPos(0)
+		m.anchor = scrollAnchor{
+			pending: true,
+			id:      top.ID(),
+			delta:   m.viewport.YOffset - m.getYOffsetForThread(top),
+		}
+	}
+	prev, prevIndex := m.curRoot, 0
+	if prev != nil {
+		prevIndex = m.threadIndex(prev)
+	}
+
+	change()
+	m.rebuild()
+	m.invalidateFrom(at)
+
+	// Nodes may have been replaced by new ones with the same ID.
+	if prev != nil {
+		if i, ok := m.index[prev.ID()]; ok {
+			m.curRoot = m.meta[i].node
+			return
+		}
+	}
+	// The selection was removed, so the node which took its place is selected instead. As the nodes are ordered
+	// depth-first, this is either a sibling or a node further up the tree.
+	m.curRoot = nil
+	m.links.active = false
+	for i := min(prevIndex, len(m.meta)-1); i >= 0; i-- {
+		if i == 0 && !m.headSelectable {
+			break
+		}
+		if m.meta[i].visible {
+			m.curRoot = m.meta[i].node
+			break
+		}
+	}
+	if m.curRoot == nil && !m.headSelectable {
+		if children := m.children(m.head); len(children) > 0 {
+			m.curRoot = children[0]
+		}
+	}
+}
+
+// invalidateFrom drops the cached views of t and its ancestors, or of all nodes if t is nil. Nodes are looked up by ID,
+// as t may have been replaced.
+func (m *Model) invalidateFrom(t Thread) {
+	if t == nil {
+		for i := range m.meta {
+			m.meta[i].cached, m.meta[i].heightValid = false, false
+		}
+		return
+	}
+	i, ok := m.index[t.ID()]
+	for ok {
+		md := &m.meta[i]
+		md.cached, md.heightValid = false, false
+		p, hasParent := md.node.Parent()
+		if !hasParent {
+			break
+		}
+		i, ok = m.index[p.ID()]
+	}
+}
+
+// restoreAnchor scrolls such that the node which was at the top of the viewport before the tree changed stays in
+// place. It must be called after layout.
+func (m *Model) restoreAnchor() {
+	if !m.anchor.pending {
+		return
+	}
+	m.anchor.pending = false
+	if i, ok := m.index[m.anchor.id]; ok && m.meta[i].visible {
+		m.viewport.SetYOffset(m.meta[i].offset + m.anchor.delta)
+	}
+}
@@ -0,0 +1,88 @@
+package threadview
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// node is a minimal `MutableThread`.
+type node struct {
+	id       int
+	parent   *node
+	children []Thread
+}
+
+func newNode(id int, children ...*node) *node {
+	n := &node{id: id}
+	for _, c := range children {
+		c.parent = n
+		n.children = append(n.children, c)
+	}
+	return n
+}
+
+func (n *node) Init() tea.Cmd                       { return nil }
+func (n *node) Update(tea.Msg) (tea.Model, tea.Cmd) { return n, nil }
+func (n *node) View() string                        { return fmt.Sprintf("node %d", n.id) }
+func (n *node) ID() int                             { return n.id }
+func (n *node) Children() []Thread                  { return n.children }
+
+func (n *node) Parent() (Thread, bool) {
+	if n.parent == nil {
+		return nil, false
+	}
+	return n.parent, true
+}
+
+func (n *node) SetChildren(children []Thread) {
+	for _, c := range children {
+		c.(*node).parent = n
+	}
+	n.children = children
+}
+
+func TestNewDuplicateID(t *testing.T) {
+	if _, err := New(newNode(1, newNode(2), newNode(2))); err == nil {
+		t.Error("expected an error for duplicate ids")
+	}
+}
+
+func TestInsertDuplicateID(t *testing.T) {
+	m, err := New(newNode(1, newNode(2), newNode(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Insert(3, -1, newNode(2)); err == nil {
+		t.Error("expected an error for duplicate ids")
+	}
+}
+
+func TestTreeChangedDuplicateID(t *testing.T) {
+	head := newNode(1, newNode(2, newNode(4)), newNode(3))
+	m, err := New(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.View()
+
+	// The duplicate is left out rather than making the model panic.
+	dup := newNode(2, newNode(5))
+	head.SetChildren(append(head.Children(), dup))
+	m.Update(TreeChangedMsg{})
+	m.View()
+	if m.numNodes != 4 {
+		t.Errorf("got %d nodes, want 4", m.numNodes)
+	}
+	if _, ok := m.index[5]; ok {
+		t.Error("descendant of duplicate was indexed")
+	}
+	m.curRoot = head.Children()[1]
+	m.nextThread()
+	m.View()
+	if m.curRoot == dup {
+		t.Error("duplicate was selected")
+	}
+}
@@ -0,0 +1,93 @@
+package threadview
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type watch struct {
+	interval time.Duration
+	// Whether a refresh is in flight, and whether it was requested through the key map.
+	busy, manual bool
+	// Ticks are only honoured if they belong to the latest schedule of this model.
+	gen int
+}
+
+type refreshTickMsg struct {
+	m   *Model
+	gen int
+}
+
+// WithRefreshInterval periodically refreshes the thread, given that its head implements `Refresher`.
+func WithRefreshInterval(d time.Duration) Option {
+	return func(m *Model) {
+		m.watch.interval = d
+	}
+}
+
+// scheduleRefresh returns a command triggering the next periodic refresh, if any.
+func (m *Model) scheduleRefresh() tea.Cmd {
+	if m.watch.interval <= 0 {
+		return nil
+	}
+	if _, ok := m.head.(Refresher); !ok {
+		return nil
+	}
+	m.watch.gen++
+	msg := refreshTickMsg{m, m.watch.gen}
+	return tea.Tick(m.watch.interval, func(time.Time) tea.Msg { return msg })
+}
+
+// refresh starts refreshing the thread, unless a refresh is already in flight.
+func (m *Model) refresh(manual bool) tea.Cmd {
+	r, ok := m.head.(Refresher)
+	if !ok {
+		if manual {
+			m.lastStatus = "Thread can not be refreshed"
+			return ClearStatusAfter(1250 * time.Millisecond)
+		}
+		return nil
+	}
+	if m.watch.busy {
+		return nil
+	}
+	m.watch.busy, m.watch.manual = true, manual
+	if manual {
+		m.lastStatus = "Refreshing..."
+	}
+	return r.Refresh()
+}
+
+func (m *Model) refreshed(msg RefreshedMsg) tea.Cmd {
+	if msg.ID != m.head.ID() {
+		return nil
+	}
+	manual := m.watch.manual
+	m.watch.busy, m.watch.manual = false, false
+	next := m.scheduleRefresh()
+	if msg.Err != nil {
+		m.lastStatus = fmt.Sprintf("Failed to refresh: %s", msg.Err.Error())
+		return tea.Batch(next, ClearStatusAfter(1250*time.Millisecond))
+	}
+
+	before := m.numNodes
+	// Any node may have been edited, so all views are re-rendered.
+	m.treeChanged(nil, func() { m.head.Update(msg) })
+
+	switch n := m.numNodes - before; {
+	case n == 1:
+		m.lastStatus = "1 new comment"
+	case n > 1:
+		m.lastStatus = fmt.Sprintf("%d new comments", n)
+	case manual:
+		m.lastStatus = "No new comments"
+	default:
+		if m.lastStatus == "Refreshing..." {
+			m.lastStatus = ""
+		}
+		return next
+	}
+	return tea.Batch(next, ClearStatusAfter(2500*time.Millisecond))
+}
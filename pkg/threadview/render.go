@@ -0,0 +1,129 @@
+package threadview
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// layoutKey holds the parts of a node's display state which determine its height. Nodes are assumed to keep their
+// height regardless of whether they are selected, part of the selected subthread or contain search matches.
+type layoutKey struct {
+	collapsed bool
+	depth     int
+	width     int
+}
+
+func layoutKeyOf(state DisplayStateMsg) layoutKey {
+	return layoutKey{state.Collapsed, state.Depth, state.Width}
+}
+
+// render returns the view of the node at index i in the given state. Views are cached until the node's state changes,
+// it is invalidated, see `Invalidate`, or the cache expires, see `viewTTL`.
+func (m *Model) render(i int, state DisplayStateMsg) string {
+	md := &m.meta[i]
+	if md.cached && md.viewState == state {
+		return md.view
+	}
+	md.node.Update(state)
+	s := md.node.View()
+	if md.loading && !state.Collapsed {
+		s += "\n" + m.loadingView(md.node, state)
+	}
+	md.view, md.viewState, md.cached = s, state, true
+	md.height = 0
+	if s != "" {
+		md.height = lipgloss.Height(s)
+	}
+	md.heightKey, md.heightValid = layoutKeyOf(state), true
+	return s
+}
+
+// viewTTL is the time after which all cached views are dropped, such that time-dependent content like relative dates
+// stays current.
+const viewTTL = time.Minute
+
+type expireViewsMsg struct{ m *Model }
+
+// expireViews returns a command dropping all cached views once they expire.
+func (m *Model) expireViews() tea.Cmd {
+	return tea.Tick(viewTTL, func(time.Time) tea.Msg { return expireViewsMsg{m} })
+}
+
+// Invalidate drops the cached view of t, e.g after its content has changed. Views are otherwise only re-rendered if the
+// node's display state changes.
+func (m *Model) Invalidate(t Thread) {
+	i := m.threadIndex(t)
+	m.meta[i].cached = false
+	m.meta[i].heightValid = false
+}
+
+// layout determines the display state, height and offset of every visible node, in display order. Nodes are only
+// rendered if their height is not known yet.
+func (m *Model) layout() {
+	m.order = m.order[:0]
+	offset := 0
+	var walk func(t Thread, state DisplayStateMsg)
+	walk = func(t Thread, state DisplayStateMsg) {
+		idx := m.threadIndex(t)
+		md := &m.meta[idx]
+		if !md.visible {
+			return
+		}
+		md.state = state
+		if !md.heightValid || md.heightKey != layoutKeyOf(state) {
+			m.render(idx, state)
+		}
+		md.offset = offset
+		offset += md.height
+		m.order = append(m.order, idx)
+
+		if state.Selected {
+			state.SubthreadDepth = state.Depth
+		}
+		state.Depth++
+		state.Subthread = cmp.Or(state.Subthread, state.Selected)
+		visible := !(m.hideCollapsedChildren && state.Collapsed)
+		for _, c := range m.children(t) {
+			cidx := m.threadIndex(c)
+			m.meta[cidx].visible = visible
+			state.Selected = c == m.curRoot
+			state.Collapsed = m.meta[cidx].collapsed
+			walk(c, state)
+		}
+	}
+	walk(m.head, DisplayStateMsg{
+		Collapsed: m.meta[m.threadIndex(m.head)].collapsed,
+		Selected:  m.curRoot == m.head,
+		Width:     m.viewport.Width,
+		Highlight: m.search.query,
+	})
+	m.contentHeight = offset
+}
+
+// content joins the views of all visible nodes. Only nodes intersecting the lines [from, to) are rendered, all others
+// are replaced by blank lines of the same height.
+func (m *Model) content(from, to int) string {
+	var b strings.Builder
+	first := true
+	for _, i := range m.order {
+		md := &m.meta[i]
+		if md.height == 0 {
+			continue
+		}
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		if md.offset < to && md.offset+md.height > from {
+			b.WriteString(m.render(i, md.state))
+		} else {
+			b.WriteString(strings.Repeat("\n", md.height-1))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package threadview
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// countingNode counts how often it is rendered.
+type countingNode struct {
+	*node
+	views int
+}
+
+func (n *countingNode) View() string {
+	n.views++
+	return n.node.View()
+}
+
+func TestViewCache(t *testing.T) {
+	head := &countingNode{node: newNode(1)}
+	m, err := New(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.View()
+	m.View()
+	if head.views != 1 {
+		t.Errorf("got %d renders, want 1", head.views)
+	}
+
+	// Views of other models are not affected.
+	other, _ := New(newNode(2))
+	m.Update(expireViewsMsg{other})
+	m.View()
+	if head.views != 1 {
+		t.Errorf("got %d renders, want 1", head.views)
+	}
+
+	if _, cmd := m.Update(expireViewsMsg{m}); cmd == nil {
+		t.Error("expected the expiry to be rescheduled")
+	}
+	m.View()
+	if head.views != 2 {
+		t.Errorf("got %d renders after expiry, want 2", head.views)
+	}
+}
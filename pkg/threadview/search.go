@@ -0,0 +1,248 @@
+package threadview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Searchable is implemented by threads supporting in-thread search. Threads not implementing this interface never match.
+type Searchable interface {
+	// SearchText returns the text which is matched against search queries.
+	SearchText() string
+}
+
+type search struct {
+	input textinput.Model
+	// Whether the query is currently being edited.
+	active   bool
+	backward bool
+	query    string
+	// Indices into the model's metadata of all matching nodes, in display order.
+	matches []int
+	// Index into matches of the current match.
+	cur int
+	// Selection prior to starting the search, restored if the search is aborted.
+	origin Thread
+}
+
+func newSearch() search {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	return search{input: ti, cur: -1}
+}
+
+// FindMatches returns the byte offsets of all occurrences of query in s. Matching is case-insensitive unless the query
+// contains upper case characters.
+func FindMatches(s, query string) [][2]int {
+	if query == "" {
+		return nil
+	}
+	var res [][2]int
+	if strings.ContainsFunc(query, unicode.IsUpper) {
+		for i := 0; ; {
+			j := strings.Index(s[i:], query)
+			if j < 0 {
+				break
+			}
+			res = append(res, [2]int{i + j, i + j + len(query)})
+			i += j + len(query)
+		}
+		return res
+	}
+	// Case folding may change the length of the text, so the query is matched against the original text rune by rune.
+	for i := 0; i < len(s); {
+		if n, ok := hasPrefixFold(s[i:], query); ok {
+			res = append(res, [2]int{i, i + n})
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return res
+}
+
+// hasPrefixFold reports whether s begins with prefix under simple case folding, along with the length of the prefix
+// within s.
+func hasPrefixFold(s, prefix string) (int, bool) {
+	n := 0
+	for _, want := range prefix {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if size == 0 || !equalFoldRune(r, want) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) startSearch(backward bool) tea.Cmd {
+	m.search.active = true
+	m.search.backward = backward
+	m.search.origin = m.curRoot
+	m.search.input.Prompt = "/"
+	if backward {
+		m.search.input.Prompt = "?"
+	}
+	m.search.input.SetValue("")
+	return m.search.input.Focus()
+}
+
+// handleSearchInput handles key presses while the search query is being edited.
+func (m *Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.search.active = false
+		m.search.input.Blur()
+		if len(m.search.matches) == 0 {
+			m.lastStatus = fmt.Sprintf("Pattern not found: %s", m.search.query)
+			m.search.query = ""
+			return m, ClearStatusAfter(1250 * time.Millisecond)
+		}
+		return m, nil
+	case tea.KeyEsc:
+		m.search.active = false
+		m.search.input.Blur()
+		m.clearSearch()
+		if m.search.origin != nil {
+			m.Select(m.search.origin)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	if q := m.search.input.Value(); q != m.search.query {
+		m.search.query = q
+		m.updateMatches()
+		// Incrementally jump to the closest match from where the search was started.
+		m.curRoot = m.search.origin
+		m.jumpToMatch(m.search.backward)
+	}
+	return m, cmd
+}
+
+func (m *Model) clearSearch() {
+	m.search.query = ""
+	m.search.matches = nil
+	m.search.cur = -1
+}
+
+func (m *Model) updateMatches() {
+	m.search.matches = m.search.matches[:0]
+	m.search.cur = -1
+	if m.search.query == "" {
+		return
+	}
+	for i := range m.meta {
+		if i == 0 && !m.headSelectable {
+			continue
+		}
+		s, ok := m.meta[i].node.(Searchable)
+		if ok && len(FindMatches(s.SearchText(), m.search.query)) > 0 {
+			m.search.matches = append(m.search.matches, i)
+		}
+	}
+}
+
+// jumpToMatch selects the next match after (or before, if backward is set) the current selection, wrapping around at the
+// end of the thread.
+func (m *Model) jumpToMatch(backward bool) {
+	n := len(m.search.matches)
+	if n == 0 {
+		return
+	}
+	cur := -1
+	if m.curRoot != nil {
+		cur = m.threadIndex(m.curRoot)
+	}
+	next := -1
+	if backward {
+		for j := n - 1; j >= 0; j-- {
+			if m.search.matches[j] < cur {
+				next = j
+				break
+			}
+		}
+		if next == -1 {
+			next = n - 1
+		}
+	} else {
+		for j := range n {
+			if m.search.matches[j] > cur || (m.search.active && m.search.matches[j] == cur) {
+				next = j
+				break
+			}
+		}
+		if next == -1 {
+			next = 0
+		}
+	}
+	m.search.cur = next
+	t := m.meta[m.search.matches[next]].node
+	m.expandTo(t)
+	m.Select(t)
+}
+
+// expandTo expands all collapsed ancestors of t, so that t becomes visible. Other collapsed nodes stay collapsed.
+func (m *Model) expandTo(t Thread) {
+	for p, ok := t.Parent(); ok; p, ok = p.Parent() {
+		i := m.threadIndex(p)
+		if !m.meta[i].collapsed {
+			continue
+		}
+		m.meta[i].collapsed = false
+		for _, c := range m.children(p) {
+			m.meta[m.threadIndex(c)].visible = true
+		}
+	}
+}
+
+func (m *Model) handleSearchKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.KeyMap.Search):
+		return true, m.startSearch(false)
+	case key.Matches(msg, m.KeyMap.SearchBackward):
+		return true, m.startSearch(true)
+	case key.Matches(msg, m.KeyMap.NextMatch) && m.search.query != "":
+		m.jumpToMatch(m.search.backward)
+		return true, nil
+	case key.Matches(msg, m.KeyMap.PrevMatch) && m.search.query != "":
+		m.jumpToMatch(!m.search.backward)
+		return true, nil
+	case key.Matches(msg, m.KeyMap.ClearSearch) && m.search.query != "":
+		m.clearSearch()
+		return true, nil
+	}
+	return false, nil
+}
+
+// searchStatus returns the match indicator shown in the status bar, if a search is ongoing.
+func (m *Model) searchStatus() string {
+	if m.search.query == "" {
+		return ""
+	}
+	if len(m.search.matches) == 0 {
+		return "no matches "
+	}
+	return fmt.Sprintf("match %d/%d ", m.search.cur+1, len(m.search.matches))
+}
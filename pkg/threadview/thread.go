@@ -13,3 +13,56 @@ type Thread interface {
 	// Children returns a list of this thread's sub-threads i.e children.
 	Children() []Thread
 }
+
+// Unreader is implemented by threads which track whether they have been read. Threads not implementing this interface
+// are considered read.
+type Unreader interface {
+	// Unread reports whether the thread is new to the reader.
+	Unread() bool
+}
+
+// LazyThread is implemented by threads whose children are loaded on demand. Threads with children which have not been
+// loaded yet start out collapsed, and their children are loaded once they are expanded.
+type LazyThread interface {
+	// NumChildren returns the number of children, including those which have not been loaded yet.
+	NumChildren() int
+	// Loaded reports whether all children have been loaded, i.e whether `Children` returns all of them.
+	Loaded() bool
+	// LoadChildren returns a command loading the thread's children, which must result in a `ChildrenLoadedMsg`.
+	LoadChildren() tea.Cmd
+}
+
+// ChildrenLoadedMsg reports the result of `LazyThread.LoadChildren`. The message is passed on to the thread with the
+// given ID, which should adopt the children (unless loading failed) before the model picks them up.
+type ChildrenLoadedMsg struct {
+	ID       int
+	Children []Thread
+	Err      error
+}
+
+// MutableThread is implemented by threads whose children may be replaced. Changing the structure of the tree through
+// `Model.ReplaceSubtree`, `Model.Insert` or `Model.Remove` requires the affected parent to implement this interface.
+type MutableThread interface {
+	// SetChildren replaces the thread's children, making the thread their parent.
+	SetChildren(children []Thread)
+}
+
+// TreeChangedMsg informs the model that the tree has been changed by other means than the model's own methods, e.g by
+// modifying nodes directly. Nodes are identified by their ID, so those which are still part of the tree keep their
+// state.
+type TreeChangedMsg struct{}
+
+// Refresher is implemented by threads which can be re-fetched, e.g to pick up new comments. The head of the thread
+// is refreshed through the key map or periodically, see `WithRefreshInterval`.
+type Refresher interface {
+	// Refresh returns a command re-fetching the thread, which must result in a `RefreshedMsg`.
+	Refresh() tea.Cmd
+}
+
+// RefreshedMsg reports the result of `Refresher.Refresh`. The message is passed on to the head of the thread, which
+// should merge the more recent copy into the tree (unless refreshing failed) before the model picks up the changes.
+type RefreshedMsg struct {
+	ID     int
+	Thread Thread
+	Err    error
+}